@@ -33,9 +33,11 @@ import (
 	"context"
 	"errors"
 	"log"
+	"log/slog"
 	"math"
 	"os"
 	"runtime"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -75,6 +77,14 @@ var (
 	// ErrInvalidPreAllocSize will be returned when trying to set up a negative capacity under PreAlloc mode.
 	ErrInvalidPreAllocSize = errors.New("can not set up a negative capacity under PreAlloc mode")
 
+	// ErrInvalidWorkerQueue will be returned when the kind of worker queue is unknown,
+	// or it's WorkerQueueFIFO with a negative capacity, or it's set up along with another
+	// queue of the idle workers, see WithShardedWorkers and WithLockFreeQueue.
+	ErrInvalidWorkerQueue = errors.New("invalid kind of worker queue")
+
+	// ErrInvalidLockFreeQueueSize will be returned when trying to set up a negative capacity under LockFreeQueue mode.
+	ErrInvalidLockFreeQueueSize = errors.New("can not set up a negative capacity under LockFreeQueue mode")
+
 	// ErrTimeout will be returned after the operations timed out.
 	ErrTimeout = errors.New("operation timed out")
 
@@ -87,6 +97,21 @@ var (
 	// ErrInvalidMultiPoolSize  will be returned when trying to create a MultiPool with an invalid size.
 	ErrInvalidMultiPoolSize = errors.New("invalid size for multiple pool")
 
+	// ErrInvalidTaskWeight will be returned when the weight of a task is less than 1 or greater than the capacity.
+	ErrInvalidTaskWeight = errors.New("invalid weight for task")
+
+	// ErrInjectedPanic is the value of the panics injected into tasks, see WithFaultInjection.
+	ErrInjectedPanic = errors.New("panic injected into the task")
+
+	// ErrRateLimited will be returned when the rate limit of the pool is exceeded and the task can't wait for it.
+	ErrRateLimited = errors.New("rate limit of the pool is exceeded")
+
+	// ErrCircuitOpen will be returned when the panic circuit breaker of the pool is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open due to repeated panics")
+
+	// ErrTaskPanicked wraps the panic of a task that is retried on panics, see RetryPolicy.RetryOnPanic.
+	ErrTaskPanicked = errors.New("task panicked")
+
 	// workerChanCap determines whether the channel of a worker should be a buffered channel
 	// to get the best performance. Inspired by fasthttp at
 	// https://github.com/valyala/fasthttp/blob/master/workerpool.go#L139
@@ -144,6 +169,61 @@ func Reboot() {
 	defaultAntsPool.Reboot()
 }
 
+// Stats is a snapshot of the statistics of a pool.
+type Stats struct {
+	// Running is the number of the currently running workers.
+	Running int
+
+	// Waiting is the number of the tasks blocked on submission.
+	Waiting int
+
+	// Free is the number of available workers, -1 indicates the pool is unlimited.
+	Free int
+
+	// Cap is the capacity of the pool, -1 indicates the pool is unlimited.
+	Cap int
+
+	// Failed is the number of tasks that returned errors.
+	Failed uint64
+
+	// Panicked is the number of tasks that panicked.
+	Panicked uint64
+
+	// Retried is the number of retries scheduled for the failed tasks.
+	Retried uint64
+
+	// Overrun is the number of tasks that were still running after their timeouts.
+	Overrun uint64
+
+	// Overloaded is the number of tasks rejected due to the capacity of the pool, i.e. with ErrPoolOverload.
+	Overloaded uint64
+
+	// RateLimited is the number of tasks rejected due to the rate limit of the pool, i.e. with ErrRateLimited.
+	RateLimited uint64
+
+	// OldestRunning is the age of the oldest running task, 0 if there is no running task
+	// or the running tasks are not tracked, see Pool.InFlight().
+	OldestRunning time.Duration
+}
+
+// PanicInfo describes a panic recovered from a task, see WithPanicHandlerV2.
+type PanicInfo struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine captured at the time of the panic.
+	Stack []byte
+
+	// Pool is the name of the pool, see WithName.
+	Pool string
+
+	// Task describes the task that panicked, its Arg is the argument of the task for the pools with functions.
+	Task TaskInfo
+
+	// Time is the time the panic was recovered.
+	Time time.Time
+}
+
 // Logger is used for logging formatted messages.
 type Logger interface {
 	// Printf must have the same semantics as log.Printf.
@@ -193,7 +273,87 @@ type poolCommon struct {
 
 	now atomic.Value
 
+	// clock is the source of time of the pool, see WithClock.
+	clock Clock
+
 	options *Options
+
+	// onIdle is called when a worker is put back into the pool or the capacity is freed up,
+	// it's used by the multi-pool in spillover mode.
+	onIdle func()
+
+	// taskID is used to generate the identifiers of tasks.
+	taskID atomic.Uint64
+
+	// failed is the number of tasks that returned errors.
+	failed atomic.Uint64
+
+	// panicked is the number of tasks that panicked.
+	panicked atomic.Uint64
+
+	// retried is the number of retries scheduled for the failed tasks.
+	retried atomic.Uint64
+
+	// overrun is the number of tasks that were still running after their timeouts.
+	overrun atomic.Uint64
+	// overrunDumped is the time in nanoseconds the goroutine stacks were last dumped for an overrunning task.
+	overrunDumped atomic.Int64
+
+	// overloaded is the number of tasks rejected due to the capacity of the pool.
+	overloaded atomic.Uint64
+
+	// rateLimited is the number of tasks rejected due to the rate limit of the pool.
+	rateLimited atomic.Uint64
+
+	// taskErrs collects the errors returned by tasks.
+	taskErrs *taskErrors
+
+	// trackers records the tasks running on all worker goroutines.
+	trackersLock sync.Mutex
+	trackers     map[*taskTracker]struct{}
+
+	// inUse is the total weight of the tasks running or about to run, see SubmitWeighted.
+	// It's updated with p.lock held, except by the lock-free fast path, see acquireFast.
+	inUse atomic.Int32
+	// queued is the length of weightWaiters, which is read by the lock-free fast path.
+	queued atomic.Int32
+	// lockFree indicates whether the plain tasks take the lock-free fast path, which requires a worker queue
+	// safe for concurrent use, see WithLockFreeQueue and WithShardedWorkers.
+	lockFree bool
+	// fixedCapacity indicates whether the capacity can't be tuned as the worker queue is bounded by it.
+	fixedCapacity bool
+	// fastAdmitted is the number of tasks admitted by the lock-free fast path.
+	fastAdmitted atomic.Uint64
+
+	// weightWaiters is the queue of callers blocked on the capacity units, in the order of their virtual finish times.
+	// It's guarded by lock, so are the others below.
+	weightWaiters []*weightWaiter
+	// workerWaiters is the number of callers that have taken their capacity units but are blocked on a worker.
+	workerWaiters int
+	// tenants are the states of the tenants sharing this pool, see SubmitForTenant.
+	tenants       map[string]*tenantState
+	defaultTenant *tenantState
+	// owed is the total capacity units reserved for the tenants but not taken by them yet.
+	owed int
+	// vtime is the virtual time of the weighted fair queueing, i.e. the start tag of the last admitted waiter.
+	vtime float64
+
+	// parent is the pool lending its capacity to this pool, on behalf of the tenant share, see NewChildPool.
+	parent *poolCommon
+	share  *tenantState
+
+	// limiter is the rate limit of task admission, nil if it's not enabled.
+	limiter *tokenBucket
+
+	// breaker is the panic circuit breaker, nil if it's not enabled.
+	breaker *circuitBreaker
+
+	// logger logs the events of the pool, it's either Options.Slogger or the adapter of Options.Logger.
+	logger *slog.Logger
+
+	// tracked indicates whether the running tasks are tracked, it's set once the pool is inspected,
+	// or from the start by WithTaskTracking or the slow task watchdog.
+	tracked atomic.Bool
 }
 
 func newPool(size int, options ...Option) (*poolCommon, error) {
@@ -211,27 +371,68 @@ func newPool(size int, options ...Option) (*poolCommon, error) {
 		}
 	}
 
+	// The idle workers are kept in only one kind of queue.
+	if (opts.ShardedWorkers && (opts.LockFreeQueue || opts.PreAlloc || opts.WorkerQueue != DefaultWorkerQueue)) ||
+		(opts.LockFreeQueue && opts.WorkerQueue != DefaultWorkerQueue) {
+		return nil, ErrInvalidWorkerQueue
+	}
+
 	if opts.Logger == nil {
 		opts.Logger = defaultLogger
 	}
+	logger := opts.Slogger
+	if logger == nil {
+		logger = slog.New(NewLoggerHandler(opts.Logger, slog.LevelError))
+	}
+	if opts.Name != "" {
+		logger = logger.With(slog.String("pool", opts.Name))
+	}
+
+	var clock Clock = realClock{}
+	if opts.Clock != nil {
+		clock = opts.Clock
+	}
+
+	newLocker := syncx.NewSpinLock
+	if opts.Locker != nil {
+		newLocker = opts.Locker
+	}
 
 	p := &poolCommon{
 		capacity: int32(size),
 		allDone:  make(chan struct{}),
-		lock:     syncx.NewSpinLock(),
+		lock:     newLocker(),
 		once:     &sync.Once{},
 		options:  opts,
+		taskErrs: newTaskErrors(),
+		trackers: make(map[*taskTracker]struct{}),
+		logger:   logger,
+		breaker:  newCircuitBreaker(opts.PanicThreshold, opts.PanicWindow, opts.PanicCooldown),
+		limiter:  newTokenBucket(opts.RateLimit, opts.RateBurst, clock.Now()),
+		clock:    clock,
 	}
-	if p.options.PreAlloc {
+	switch {
+	case p.options.ShardedWorkers:
+		p.workers = newWorkerQueue(queueTypeSharded, runtime.GOMAXPROCS(0))
+		p.lockFree = len(opts.Tenants) == 0
+	case p.options.LockFreeQueue:
 		if size == -1 {
-			return nil, ErrInvalidPreAllocSize
+			return nil, ErrInvalidLockFreeQueueSize
 		}
-		p.workers = newWorkerQueue(queueTypeLoopQueue, size)
-	} else {
-		p.workers = newWorkerQueue(queueTypeStack, 0)
+		p.workers = newWorkerQueue(queueTypeLockFree, size)
+		p.lockFree = len(opts.Tenants) == 0
+		p.fixedCapacity = true
+	default:
+		var err error
+		if p.workers, err = newKindOfWorkerQueue(opts.WorkerQueue, size, opts.PreAlloc); err != nil {
+			return nil, err
+		}
+		p.fixedCapacity = opts.PreAlloc || opts.WorkerQueue == WorkerQueueFIFO
 	}
 
 	p.cond = sync.NewCond(p.lock)
+	p.initTenants()
+	p.tracked.Store(opts.TaskTracking || opts.SlowTaskHandler != nil)
 
 	p.goPurge()
 	p.goTicktock()
@@ -241,7 +442,7 @@ func newPool(size int, options ...Option) (*poolCommon, error) {
 
 // purgeStaleWorkers clears stale workers periodically, it runs in an individual goroutine, as a scavenger.
 func (p *poolCommon) purgeStaleWorkers() {
-	ticker := time.NewTicker(p.options.ExpiryDuration)
+	ticker := p.clock.NewTicker(p.options.ExpiryDuration)
 
 	defer func() {
 		ticker.Stop()
@@ -253,31 +454,37 @@ func (p *poolCommon) purgeStaleWorkers() {
 		select {
 		case <-purgeCtx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.Chan():
 		}
 
 		if p.IsClosed() {
 			break
 		}
 
-		var isDormant bool
-		p.lock.Lock()
-		staleWorkers := p.workers.refresh(p.options.ExpiryDuration)
-		n := p.Running()
-		isDormant = n == 0 || n == len(staleWorkers)
-		p.lock.Unlock()
+		p.purgeStale()
+	}
+}
 
-		// Clean up the stale workers.
-		for i := range staleWorkers {
-			staleWorkers[i].finish()
-			staleWorkers[i] = nil
-		}
+// purgeStale clears the workers that haven't been used for more than ExpiryDuration.
+func (p *poolCommon) purgeStale() {
+	var isDormant bool
+	p.lock.Lock()
+	staleWorkers := p.workers.refresh(p.clock.Now(), p.options.ExpiryDuration)
+	n := p.Running()
+	isDormant = n == 0 || n == len(staleWorkers)
+	p.lock.Unlock()
 
-		// There might be a situation where all workers have been cleaned up (no worker is running),
-		// while some invokers still are stuck in p.cond.Wait(), then we need to awake those invokers.
-		if isDormant && p.Waiting() > 0 {
-			p.cond.Broadcast()
-		}
+	// Clean up the stale workers.
+	for i := range staleWorkers {
+		staleWorkers[i].finish()
+		staleWorkers[i] = nil
+	}
+	p.logger.Debug("purged stale workers", slog.Int("purged", len(staleWorkers)), slog.Int("running", n))
+
+	// There might be a situation where all workers have been cleaned up (no worker is running),
+	// while some invokers still are stuck in p.cond.Wait(), then we need to awake those invokers.
+	if isDormant && p.Waiting() > 0 {
+		p.cond.Broadcast()
 	}
 }
 
@@ -285,7 +492,7 @@ const nowTimeUpdateInterval = 500 * time.Millisecond
 
 // ticktock is a goroutine that updates the current time in the pool regularly.
 func (p *poolCommon) ticktock() {
-	ticker := time.NewTicker(nowTimeUpdateInterval)
+	ticker := p.clock.NewTicker(nowTimeUpdateInterval)
 	defer func() {
 		ticker.Stop()
 		atomic.StoreInt32(&p.ticktockDone, 1)
@@ -296,17 +503,23 @@ func (p *poolCommon) ticktock() {
 		select {
 		case <-ticktockCtx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.Chan():
 		}
 
 		if p.IsClosed() {
 			break
 		}
 
-		p.now.Store(time.Now())
+		p.tick(p.clock.Now())
 	}
 }
 
+// tick updates the current time in the pool and checks the running tasks against it.
+func (p *poolCommon) tick(now time.Time) {
+	p.now.Store(now)
+	p.checkSlowTasks(now)
+}
+
 func (p *poolCommon) goPurge() {
 	if p.options.DisablePurge {
 		return
@@ -314,12 +527,20 @@ func (p *poolCommon) goPurge() {
 
 	// Start a goroutine to clean up expired workers periodically.
 	p.purgeCtx, p.stopPurge = context.WithCancel(context.Background())
+	if p.options.SharedRuntime && p.options.Clock == nil {
+		p.stopPurge = shared.addScavenged(p, p.stopPurge)
+		return
+	}
 	go p.purgeStaleWorkers()
 }
 
 func (p *poolCommon) goTicktock() {
-	p.now.Store(time.Now())
+	p.now.Store(p.clock.Now())
 	p.ticktockCtx, p.stopTicktock = context.WithCancel(context.Background())
+	if p.options.SharedRuntime && p.options.Clock == nil {
+		p.stopTicktock = shared.addClocked(p, p.stopTicktock)
+		return
+	}
 	go p.ticktock()
 }
 
@@ -327,6 +548,12 @@ func (p *poolCommon) nowTime() time.Time {
 	return p.now.Load().(time.Time)
 }
 
+// afterFunc calls f in its own goroutine after the duration elapses unless it's stopped,
+// this is how the pool delays tasks without occupying any worker.
+func (p *poolCommon) afterFunc(d time.Duration, f func()) (stop func() bool) {
+	return p.clock.AfterFunc(d, f).Stop
+}
+
 // Running returns the number of workers currently running.
 func (p *poolCommon) Running() int {
 	return int(atomic.LoadInt32(&p.running))
@@ -351,16 +578,42 @@ func (p *poolCommon) Cap() int {
 	return int(atomic.LoadInt32(&p.capacity))
 }
 
-// Tune changes the capacity of this pool, note that it is noneffective to the infinite or pre-allocation pool.
+// Stats returns a snapshot of the statistics of this pool.
+func (p *poolCommon) Stats() Stats {
+	return Stats{
+		Running:       p.Running(),
+		Waiting:       p.Waiting(),
+		Free:          p.Free(),
+		Cap:           p.Cap(),
+		Failed:        p.failed.Load(),
+		Panicked:      p.panicked.Load(),
+		Retried:       p.retried.Load(),
+		Overrun:       p.overrun.Load(),
+		Overloaded:    p.overloaded.Load(),
+		RateLimited:   p.rateLimited.Load(),
+		OldestRunning: p.oldestRunning(p.clock.Now()),
+	}
+}
+
+// Wait blocks until all error-returning tasks submitted to this pool are done,
+// then returns the errors they returned joined by errors.Join, or nil if there is none.
+// The collected errors are discarded once they are returned.
+func (p *poolCommon) Wait() error {
+	return p.taskErrs.wait()
+}
+
+// Tune changes the capacity of this pool, note that it is noneffective to the infinite, pre-allocation
+// or FIFO/lock-free queue pool.
 func (p *poolCommon) Tune(size int) {
 	capacity := p.Cap()
-	if capacity == -1 || size <= 0 || size == capacity || p.options.PreAlloc {
+	if capacity == -1 || size <= 0 || size == capacity || p.fixedCapacity {
 		return
 	}
 	atomic.StoreInt32(&p.capacity, int32(size))
 	if size > capacity {
+		p.notifyIdle()
 		if size-capacity == 1 {
-			p.cond.Signal()
+			p.signal()
 			return
 		}
 		p.cond.Broadcast()
@@ -378,6 +631,10 @@ func (p *poolCommon) Release() {
 		return
 	}
 
+	if p.parent != nil {
+		p.leaveParent()
+	}
+
 	if p.stopPurge != nil {
 		p.stopPurge()
 		p.stopPurge = nil
@@ -394,6 +651,7 @@ func (p *poolCommon) Release() {
 	// There might be some callers waiting in retrieveWorker(), so we need to wake them up to prevent
 	// those callers blocking infinitely.
 	p.cond.Broadcast()
+	p.logger.Info("pool released", slog.Int("running", p.Running()), slog.Int("waiting", p.Waiting()))
 }
 
 // ReleaseTimeout is like Release but with a timeout, it waits all workers to exit before timing out.
@@ -403,7 +661,11 @@ func (p *poolCommon) ReleaseTimeout(timeout time.Duration) error {
 	}
 
 	p.Release()
+	return p.waitReleased(timeout)
+}
 
+// waitReleased waits all workers of the released pool to exit before timing out.
+func (p *poolCommon) waitReleased(timeout time.Duration) error {
 	var purgeCh <-chan struct{}
 	if !p.options.DisablePurge {
 		purgeCh = p.purgeCtx.Done()
@@ -422,6 +684,7 @@ func (p *poolCommon) ReleaseTimeout(timeout time.Duration) error {
 	for {
 		select {
 		case <-timer.C:
+			p.logger.Warn("pool release timed out", slog.Duration("timeout", timeout), slog.Int("running", p.Running()))
 			return ErrTimeout
 		case <-p.allDone:
 			<-purgeCh
@@ -441,12 +704,49 @@ func (p *poolCommon) ReleaseTimeout(timeout time.Duration) error {
 // before rebooting, otherwise you may run into data race.
 func (p *poolCommon) Reboot() {
 	if atomic.CompareAndSwapInt32(&p.state, CLOSED, OPENED) {
+		if p.parent != nil {
+			p.joinParent()
+		}
 		atomic.StoreInt32(&p.purgeDone, 0)
 		p.goPurge()
 		atomic.StoreInt32(&p.ticktockDone, 0)
 		p.goTicktock()
 		p.allDone = make(chan struct{})
 		p.once = &sync.Once{}
+		p.logger.Info("pool rebooted", slog.Int("capacity", p.Cap()))
+	}
+}
+
+// handlePanic handles the panic of a task, it's called by the worker goroutine that recovered from the panic.
+func (p *poolCommon) handlePanic(r any, info TaskInfo) {
+	p.panicked.Add(1)
+	if p.breaker != nil && p.breaker.recordPanic(p.clock.Now()) {
+		p.logger.Warn("circuit breaker opened", slog.Int("threshold", p.breaker.threshold),
+			slog.Duration("window", p.breaker.window), slog.Duration("cooldown", p.breaker.cooldown))
+	}
+	if ph := p.options.PanicHandlerV2; ph != nil {
+		ph(PanicInfo{
+			Value: r,
+			Stack: debug.Stack(),
+			Pool:  p.options.Name,
+			Task:  info,
+			Time:  p.clock.Now(),
+		})
+	} else if ph := p.options.PanicHandler; ph != nil {
+		ph(r)
+	} else if p.options.Slogger == nil {
+		// The panics are written to the plain Logger in the format it has always had,
+		// which the users might be parsing.
+		p.options.Logger.Printf("worker exits from panic: %v\n%s\n", r, debug.Stack())
+	} else {
+		attrs := []any{slog.Any("panic", r), slog.String("stack", string(debug.Stack()))}
+		if info.ID != 0 {
+			attrs = append(attrs, slog.Uint64("task_id", info.ID))
+		}
+		if info.Name != "" {
+			attrs = append(attrs, slog.String("task_name", info.Name))
+		}
+		p.logger.Error("worker exits from panic", attrs...)
 	}
 }
 
@@ -458,14 +758,85 @@ func (p *poolCommon) addWaiting(delta int) {
 	atomic.AddInt32(&p.waiting, int32(delta))
 }
 
+func (p *poolCommon) nextTaskID() uint64 {
+	return p.taskID.Add(1)
+}
+
+func (p *poolCommon) newTaskInfo() *TaskInfo {
+	return &TaskInfo{ID: p.nextTaskID(), SubmitTime: p.nowTime()}
+}
+
+// taskDone handles the result of an error-returning task.
+func (p *poolCommon) taskDone(err error, info TaskInfo) {
+	defer p.taskErrs.done(err)
+	if err == nil {
+		return
+	}
+	p.failed.Add(1)
+	if eh := p.options.ErrorHandler; eh != nil {
+		eh(err, info)
+	}
+}
+
 // retrieveWorker returns an available worker to run the tasks.
-func (p *poolCommon) retrieveWorker() (w worker, err error) {
+func (p *poolCommon) retrieveWorker(f *fault) (w worker, err error) {
+	return p.retrieveWeightedWorker("", 1, f)
+}
+
+// retrieveWeightedWorker returns an available worker to run the task of the tenant
+// that weighs the given capacity units. f is the fault of the submission handed over by
+// the multi-pool owning this pool, the pool draws the fault itself if it's nil.
+func (p *poolCommon) retrieveWeightedWorker(tenant string, weight int, f *fault) (w worker, err error) {
+	if p.breaker != nil {
+		settle, err := p.admitTask()
+		if err != nil {
+			return nil, err
+		}
+		if settle != nil {
+			defer func() { settle(w) }()
+		}
+	}
+	if p.limiter != nil {
+		if err = p.waitRateLimit(true); err != nil {
+			return nil, err
+		}
+	}
+	if f != nil || p.options.FaultInjection != nil {
+		settle, err := p.injectFault(true, f)
+		if err != nil {
+			return nil, err
+		}
+		if settle != nil {
+			defer func() { settle(w) }()
+		}
+	}
+
+	if p.lockFree && tenant == "" && weight == 1 {
+		if w = p.retrieveFast(); w != nil {
+			return
+		}
+	}
+
 	p.lock.Lock()
 
+	// Reserve the capacity units for the task before retrieving a worker for it.
+	ts := p.tenantLocked(tenant)
+	if err = p.reserveWeight(ts, weight, true); err != nil {
+		p.lock.Unlock()
+		return nil, err
+	}
+	if p.parent != nil {
+		if err = p.borrowLocked(ts, weight, true); err != nil {
+			p.lock.Unlock()
+			return nil, err
+		}
+	}
+
 retry:
 	// First try to fetch the worker from the queue.
 	if w = p.workers.detach(); w != nil {
 		p.lock.Unlock()
+		w.tracked().hold(ts, weight)
 		return
 	}
 
@@ -475,21 +846,37 @@ retry:
 		w = p.workerCache.Get().(worker)
 		w.run()
 		p.lock.Unlock()
+		w.tracked().hold(ts, weight)
 		return
 	}
 
 	// Bail out early if it's in nonblocking mode or the number of pending callers reaches the maximum limit value.
 	if p.options.Nonblocking || (p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks) {
+		p.unreserveLocked(ts, weight)
 		p.lock.Unlock()
+		p.overloaded.Add(1)
 		return nil, ErrPoolOverload
 	}
 
 	// Otherwise, we'll have to keep them blocked and wait for at least one worker to be put back into pool.
 	p.addWaiting(1)
+	// The workers are put back without the lock in the lock-free mode, which doesn't signal
+	// until it sees this caller waiting, so check again in case it has just missed it.
+	if p.lockFree {
+		if w = p.workers.detach(); w != nil {
+			p.addWaiting(-1)
+			p.lock.Unlock()
+			w.tracked().hold(ts, weight)
+			return
+		}
+	}
+	p.workerWaiters++
 	p.cond.Wait() // block and wait for an available worker
+	p.workerWaiters--
 	p.addWaiting(-1)
 
 	if p.IsClosed() {
+		p.unreserveLocked(ts, weight)
 		p.lock.Unlock()
 		return nil, ErrPoolClosed
 	}
@@ -497,6 +884,73 @@ retry:
 	goto retry
 }
 
+// tryRetrieveWorker is like retrieveWorker but returns ErrPoolOverload instead of blocking.
+func (p *poolCommon) tryRetrieveWorker(f *fault) (w worker, err error) {
+	if p.breaker != nil {
+		settle, err := p.admitTask()
+		if err != nil {
+			return nil, err
+		}
+		if settle != nil {
+			defer func() { settle(w) }()
+		}
+	}
+	if p.limiter != nil {
+		if err = p.waitRateLimit(false); err != nil {
+			return nil, err
+		}
+	}
+	if f != nil || p.options.FaultInjection != nil {
+		settle, err := p.injectFault(false, f)
+		if err != nil {
+			return nil, err
+		}
+		if settle != nil {
+			defer func() { settle(w) }()
+		}
+	}
+
+	if p.lockFree {
+		if w = p.retrieveFast(); w != nil {
+			return
+		}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	ts := p.tenantLocked("")
+	if err = p.reserveWeight(ts, 1, false); err != nil {
+		return nil, err
+	}
+	if p.parent != nil {
+		if err = p.borrowLocked(ts, 1, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if w = p.workers.detach(); w != nil {
+		w.tracked().hold(ts, 1)
+		return
+	}
+
+	if capacity := p.Cap(); capacity == -1 || capacity > p.Running() {
+		w = p.workerCache.Get().(worker)
+		w.run()
+		w.tracked().hold(ts, 1)
+		return
+	}
+
+	p.unreserveLocked(ts, 1)
+	return nil, ErrPoolOverload
+}
+
+func (p *poolCommon) notifyIdle() {
+	if p.onIdle != nil {
+		p.onIdle()
+	}
+}
+
 // revertWorker puts a worker back into free pool, recycling the goroutines.
 func (p *poolCommon) revertWorker(worker worker) bool {
 	if capacity := p.Cap(); (capacity > 0 && p.Running() > capacity) || p.IsClosed() {
@@ -506,7 +960,12 @@ func (p *poolCommon) revertWorker(worker worker) bool {
 
 	worker.setLastUsedTime(p.nowTime())
 
+	if p.lockFree && worker.tracked().tenant == nil {
+		return p.revertFast(worker)
+	}
+
 	p.lock.Lock()
+	p.releaseWeightLocked(worker.tracked())
 	// To avoid memory leaks, add a double check in the lock scope.
 	// Issue: https://github.com/panjf2000/ants/issues/113
 	if p.IsClosed() {
@@ -518,8 +977,9 @@ func (p *poolCommon) revertWorker(worker worker) bool {
 		return false
 	}
 	// Notify the invoker stuck in 'retrieveWorker()' of there is an available worker in the worker queue.
-	p.cond.Signal()
+	p.signalLocked()
 	p.lock.Unlock()
+	p.notifyIdle()
 
 	return true
 }
@@ -23,6 +23,7 @@
 package ants_test
 
 import (
+	"context"
 	"runtime"
 	"sync"
 	"sync/atomic"
@@ -122,6 +123,26 @@ func BenchmarkErrGroup(b *testing.B) {
 	}
 }
 
+func BenchmarkAntsGroup(b *testing.B) {
+	var wg sync.WaitGroup
+	p, _ := ants.NewPool(PoolCap, ants.WithExpiryDuration(DefaultExpiredTime))
+	defer p.Release()
+	pool, _ := ants.NewGroup(context.Background(), p)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		wg.Add(RunTimes)
+		for j := 0; j < RunTimes; j++ {
+			pool.Go(func() error {
+				demoFunc()
+				wg.Done()
+				return nil
+			})
+		}
+		wg.Wait()
+	}
+}
+
 func BenchmarkAntsPool(b *testing.B) {
 	var wg sync.WaitGroup
 	p, _ := ants.NewPool(PoolCap, ants.WithExpiryDuration(DefaultExpiredTime))
@@ -215,6 +236,80 @@ func BenchmarkParallelAntsPoolThroughput(b *testing.B) {
 	})
 }
 
+func BenchmarkParallelAntsPoolLockFreeThroughput(b *testing.B) {
+	p, _ := ants.NewPool(PoolCap, ants.WithExpiryDuration(DefaultExpiredTime), ants.WithLockFreeQueue(true))
+	defer p.Release()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = p.Submit(demoFunc)
+		}
+	})
+}
+
+// BenchmarkParallelAntsPoolHandoff measures the cost of handing the tasks over to the idle workers,
+// which is dominated by the contention on the worker queue as the tasks do nothing.
+func BenchmarkParallelAntsPoolHandoff(b *testing.B) {
+	modes := []struct {
+		name string
+		opts []ants.Option
+	}{
+		{"Stack", nil},
+		{"LoopQueue", []ants.Option{ants.WithPreAlloc(true)}},
+		{"LockFreeQueue", []ants.Option{ants.WithLockFreeQueue(true)}},
+		{"ShardedWorkers", []ants.Option{ants.WithShardedWorkers(true)}},
+	}
+	for _, m := range modes {
+		b.Run(m.name, func(b *testing.B) {
+			p, _ := ants.NewPool(runtime.GOMAXPROCS(0)*4, append(m.opts, ants.WithExpiryDuration(DefaultExpiredTime))...)
+			defer p.Release()
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = p.Submit(func() {})
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkAntsPoolBlockedSubmitters measures the cost of the submissions blocked on a small pool,
+// where every worker put back has a crowd of callers to hand over to.
+func BenchmarkAntsPoolBlockedSubmitters(b *testing.B) {
+	const submitters = 2000
+	modes := []struct {
+		name string
+		opts []ants.Option
+	}{
+		{"Stack", nil},
+		{"LockFreeQueue", []ants.Option{ants.WithLockFreeQueue(true)}},
+	}
+	for _, m := range modes {
+		b.Run(m.name, func(b *testing.B) {
+			p, _ := ants.NewPool(8, append(m.opts, ants.WithExpiryDuration(DefaultExpiredTime))...)
+			defer p.Release()
+
+			var wg sync.WaitGroup
+			wg.Add(b.N)
+			b.ResetTimer()
+			for i := 0; i < submitters; i++ {
+				n := b.N / submitters
+				if i < b.N%submitters {
+					n++
+				}
+				go func(n int) {
+					for j := 0; j < n; j++ {
+						_ = p.Submit(wg.Done)
+					}
+				}(n)
+			}
+			wg.Wait()
+		})
+	}
+}
+
 func BenchmarkParallelAntsMultiPoolThroughput(b *testing.B) {
 	p, _ := ants.NewMultiPool(10, PoolCap/10, ants.RoundRobin, ants.WithExpiryDuration(DefaultExpiredTime))
 	defer p.ReleaseTimeout(DefaultExpiredTime) //nolint:errcheck
@@ -226,3 +321,29 @@ func BenchmarkParallelAntsMultiPoolThroughput(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkParallelAntsMultiPoolStrategies(b *testing.B) {
+	strategies := []struct {
+		name string
+		lbs  ants.LoadBalancingStrategy
+	}{
+		{"RoundRobin", ants.RoundRobin},
+		{"LeastTasks", ants.LeastTasks},
+		{"PowerOfTwoChoices", ants.PowerOfTwoChoices},
+		{"LeastWaiting", ants.LeastWaiting},
+		{"WeightedRoundRobin", ants.WeightedRoundRobin},
+	}
+	for _, s := range strategies {
+		b.Run(s.name, func(b *testing.B) {
+			p, _ := ants.NewMultiPool(50, PoolCap/50, s.lbs, ants.WithExpiryDuration(DefaultExpiredTime))
+			defer p.ReleaseTimeout(DefaultExpiredTime) //nolint:errcheck
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_ = p.Submit(demoFunc)
+				}
+			})
+		})
+	}
+}
@@ -23,9 +23,17 @@
 package ants_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -34,6 +42,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/panjf2000/ants/v2"
+	"github.com/panjf2000/ants/v2/pkg/antstest"
+	syncx "github.com/panjf2000/ants/v2/pkg/sync"
 )
 
 const (
@@ -440,34 +450,36 @@ func TestPurgePool(t *testing.T) {
 	size := 500
 	ch := make(chan struct{})
 
-	p, err := ants.NewPool(size)
+	clock := antstest.NewFakeClock(time.Now())
+	p, err := ants.NewPool(size, ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPool failed: %v", err)
 	defer p.Release()
+	// Wait for the tickers of the scavenger and the clock of the pool.
+	clock.BlockUntil(2)
 
 	for i := 0; i < size; i++ {
-		j := i + 1
 		_ = p.Submit(func() {
 			<-ch
-			d := j % 100
-			time.Sleep(time.Duration(d) * time.Millisecond)
 		})
 	}
 	require.EqualValuesf(t, size, p.Running(), "pool should be full, expected: %d, but got: %d", size, p.Running())
 
 	close(ch)
-	time.Sleep(5 * ants.DefaultCleanIntervalTime)
-	require.EqualValuesf(t, 0, p.Running(), "pool should be empty after purge, but got %d", p.Running())
+	require.Eventually(t, func() bool { return len(p.IdleWorkers()) == size }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventuallyf(t, func() bool { return p.Running() == 0 }, time.Second, time.Millisecond,
+		"pool should be empty after purge, but got %d", p.Running())
 
 	ch = make(chan struct{})
-	f := func(i any) {
+	f := func(any) {
 		<-ch
-		d := i.(int) % 100
-		time.Sleep(time.Duration(d) * time.Millisecond)
 	}
 
-	p1, err := ants.NewPoolWithFunc(size, f)
+	clock = antstest.NewFakeClock(time.Now())
+	p1, err := ants.NewPoolWithFunc(size, f, ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPoolWithFunc failed: %v", err)
 	defer p1.Release()
+	clock.BlockUntil(2)
 
 	for i := 0; i < size; i++ {
 		_ = p1.Invoke(i)
@@ -475,19 +487,21 @@ func TestPurgePool(t *testing.T) {
 	require.EqualValuesf(t, size, p1.Running(), "pool should be full, expected: %d, but got: %d", size, p1.Running())
 
 	close(ch)
-	time.Sleep(5 * ants.DefaultCleanIntervalTime)
-	require.EqualValuesf(t, 0, p1.Running(), "pool should be empty after purge, but got %d", p1.Running())
+	require.Eventually(t, func() bool { return len(p1.IdleWorkers()) == size }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventuallyf(t, func() bool { return p1.Running() == 0 }, time.Second, time.Millisecond,
+		"pool should be empty after purge, but got %d", p1.Running())
 
 	ch = make(chan struct{})
-	f1 := func(i int) {
+	f1 := func(int) {
 		<-ch
-		d := i % 100
-		time.Sleep(time.Duration(d) * time.Millisecond)
 	}
 
-	p2, err := ants.NewPoolWithFuncGeneric(size, f1)
+	clock = antstest.NewFakeClock(time.Now())
+	p2, err := ants.NewPoolWithFuncGeneric(size, f1, ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPoolWithFunc failed: %v", err)
 	defer p2.Release()
+	clock.BlockUntil(2)
 
 	for i := 0; i < size; i++ {
 		_ = p2.Invoke(i)
@@ -495,31 +509,42 @@ func TestPurgePool(t *testing.T) {
 	require.EqualValuesf(t, size, p2.Running(), "pool should be full, expected: %d, but got: %d", size, p2.Running())
 
 	close(ch)
-	time.Sleep(5 * ants.DefaultCleanIntervalTime)
-	require.EqualValuesf(t, 0, p2.Running(), "pool should be empty after purge, but got %d", p2.Running())
+	require.Eventually(t, func() bool { return len(p2.IdleWorkers()) == size }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventuallyf(t, func() bool { return p2.Running() == 0 }, time.Second, time.Millisecond,
+		"pool should be empty after purge, but got %d", p2.Running())
 }
 
 func TestPurgePreMallocPool(t *testing.T) {
-	p, err := ants.NewPool(10, ants.WithPreAlloc(true))
+	clock := antstest.NewFakeClock(time.Now())
+	p, err := ants.NewPool(10, ants.WithPreAlloc(true), ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPool failed: %v", err)
 	defer p.Release()
+	clock.BlockUntil(2)
 	_ = p.Submit(demoFunc)
-	time.Sleep(3 * ants.DefaultCleanIntervalTime)
-	require.EqualValues(t, 0, p.Running(), "all p should be purged")
+	require.Eventually(t, func() bool { return len(p.IdleWorkers()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventually(t, func() bool { return p.Running() == 0 }, time.Second, time.Millisecond, "all p should be purged")
 
-	p1, err := ants.NewPoolWithFunc(10, demoPoolFunc)
+	clock = antstest.NewFakeClock(time.Now())
+	p1, err := ants.NewPoolWithFunc(10, demoPoolFunc, ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPoolWithFunc failed: %v", err)
 	defer p1.Release()
+	clock.BlockUntil(2)
 	_ = p1.Invoke(1)
-	time.Sleep(3 * ants.DefaultCleanIntervalTime)
-	require.EqualValues(t, 0, p1.Running(), "all p should be purged")
+	require.Eventually(t, func() bool { return len(p1.IdleWorkers()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventually(t, func() bool { return p1.Running() == 0 }, time.Second, time.Millisecond, "all p should be purged")
 
-	p2, err := ants.NewPoolWithFuncGeneric(10, demoPoolFuncInt)
+	clock = antstest.NewFakeClock(time.Now())
+	p2, err := ants.NewPoolWithFuncGeneric(10, demoPoolFuncInt, ants.WithClock(clock))
 	require.NoErrorf(t, err, "create TimingPoolWithFunc failed: %v", err)
 	defer p2.Release()
+	clock.BlockUntil(2)
 	_ = p2.Invoke(1)
-	time.Sleep(3 * ants.DefaultCleanIntervalTime)
-	require.EqualValues(t, 0, p2.Running(), "all p should be purged")
+	require.Eventually(t, func() bool { return len(p2.IdleWorkers()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(ants.DefaultCleanIntervalTime)
+	require.Eventually(t, func() bool { return p2.Running() == 0 }, time.Second, time.Millisecond, "all p should be purged")
 }
 
 func TestNonblockingSubmit(t *testing.T) {
@@ -1352,9 +1377,9 @@ func TestDefaultPoolReleaseTimeout(t *testing.T) {
 }
 
 func TestMultiPool(t *testing.T) {
-	_, err := ants.NewMultiPool(-1, 10, 8)
+	_, err := ants.NewMultiPool(-1, 10, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidMultiPoolSize)
-	_, err = ants.NewMultiPool(10, -1, 8)
+	_, err = ants.NewMultiPool(10, -1, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidLoadBalancingStrategy)
 	_, err = ants.NewMultiPool(10, 10, ants.RoundRobin, ants.WithExpiryDuration(-1))
 	require.ErrorIs(t, err, ants.ErrInvalidPoolExpiry)
@@ -1409,13 +1434,25 @@ func TestMultiPool(t *testing.T) {
 	mp.Reboot()
 	testFn()
 
+	mp, err = ants.NewMultiPool(10, 5, ants.LeastWaiting)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
+	mp, err = ants.NewMultiPool(10, 5, ants.WeightedRoundRobin)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
 	mp.Tune(10)
 }
 
 func TestMultiPoolWithFunc(t *testing.T) {
-	_, err := ants.NewMultiPoolWithFunc(-1, 10, longRunningPoolFunc, 8)
+	_, err := ants.NewMultiPoolWithFunc(-1, 10, longRunningPoolFunc, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidMultiPoolSize)
-	_, err = ants.NewMultiPoolWithFunc(10, -1, longRunningPoolFunc, 8)
+	_, err = ants.NewMultiPoolWithFunc(10, -1, longRunningPoolFunc, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidLoadBalancingStrategy)
 	_, err = ants.NewMultiPoolWithFunc(10, 10, longRunningPoolFunc, ants.RoundRobin, ants.WithExpiryDuration(-1))
 	require.ErrorIs(t, err, ants.ErrInvalidPoolExpiry)
@@ -1471,13 +1508,25 @@ func TestMultiPoolWithFunc(t *testing.T) {
 	mp.Reboot()
 	testFn()
 
+	mp, err = ants.NewMultiPoolWithFunc(10, 5, longRunningPoolFunc, ants.LeastWaiting)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
+	mp, err = ants.NewMultiPoolWithFunc(10, 5, longRunningPoolFunc, ants.WeightedRoundRobin)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
 	mp.Tune(10)
 }
 
 func TestMultiPoolWithFuncGeneric(t *testing.T) {
-	_, err := ants.NewMultiPoolWithFuncGeneric(-1, 10, longRunningPoolFuncCh, 8)
+	_, err := ants.NewMultiPoolWithFuncGeneric(-1, 10, longRunningPoolFuncCh, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidMultiPoolSize)
-	_, err = ants.NewMultiPoolWithFuncGeneric(10, -1, longRunningPoolFuncCh, 8)
+	_, err = ants.NewMultiPoolWithFuncGeneric(10, -1, longRunningPoolFuncCh, 0)
 	require.ErrorIs(t, err, ants.ErrInvalidLoadBalancingStrategy)
 	_, err = ants.NewMultiPoolWithFuncGeneric(10, 10, longRunningPoolFuncCh, ants.RoundRobin, ants.WithExpiryDuration(-1))
 	require.ErrorIs(t, err, ants.ErrInvalidPoolExpiry)
@@ -1533,7 +1582,2040 @@ func TestMultiPoolWithFuncGeneric(t *testing.T) {
 	mp.Reboot()
 	testFn()
 
+	mp, err = ants.NewMultiPoolWithFuncGeneric(10, 5, longRunningPoolFuncCh, ants.LeastWaiting)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
+	mp, err = ants.NewMultiPoolWithFuncGeneric(10, 5, longRunningPoolFuncCh, ants.WeightedRoundRobin)
+	testFn()
+
+	mp.Reboot()
+	testFn()
+
+	mp.Tune(10)
+}
+
+func TestMultiPoolPowerOfTwoChoices(t *testing.T) {
+	mp, err := ants.NewMultiPool(10, 10, ants.PowerOfTwoChoices, ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer mp.ReleaseTimeout(time.Second) //nolint:errcheck
+
+	// Submitting half of the capacity never overloads the pools since the less loaded one
+	// of the two sampled pools always has a free worker.
+	for i := 0; i < 50; i++ {
+		require.NoError(t, mp.Submit(longRunningFunc))
+	}
+	require.EqualValues(t, 50, mp.Running())
+	for i := 0; i < 10; i++ {
+		n, _ := mp.RunningByIndex(i)
+		require.LessOrEqual(t, n, 10)
+	}
+	atomic.StoreInt32(&stopLongRunningFunc, 1)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	atomic.StoreInt32(&stopLongRunningFunc, 0)
+
+	mp1, err := ants.NewMultiPool(1, 10, ants.PowerOfTwoChoices)
+	require.NoError(t, err)
+	defer mp1.ReleaseTimeout(time.Second) //nolint:errcheck
+	require.NoError(t, mp1.Submit(demoFunc))
+}
+
+func TestMultiPoolLoadBalancer(t *testing.T) {
+	var infos []ants.PoolInfo
+	balancer := func(pools []ants.PoolInfo) int {
+		infos = pools
+		return 3
+	}
+	ch := make(chan struct{})
+	mp, err := ants.NewMultiPoolWithFunc(5, 10, longRunningPoolFunc, ants.RoundRobin, ants.WithLoadBalancer(balancer))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, mp.Invoke(ch))
+	}
+	n, _ := mp.RunningByIndex(3)
+	require.EqualValues(t, 10, n)
+	require.Len(t, infos, 5)
+	require.EqualValues(t, ants.PoolInfo{Running: 9, Free: 1, Cap: 10}, infos[3])
+	close(ch)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+
+	// An index out of range falls back to the load-balancing strategy.
+	ch = make(chan struct{})
+	mpg, err := ants.NewMultiPoolWithFuncGeneric(5, 10, longRunningPoolFuncCh, ants.LeastTasks,
+		ants.WithLoadBalancer(func([]ants.PoolInfo) int { return -1 }))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, mpg.Invoke(ch))
+	}
+	for i := 0; i < 5; i++ {
+		n, _ = mpg.RunningByIndex(i)
+		require.EqualValues(t, 2, n)
+	}
+	close(ch)
+	require.NoError(t, mpg.ReleaseTimeout(3*time.Second))
+}
+
+func TestMultiPoolResize(t *testing.T) {
+	// Select the least loaded pool, the last one wins the tie.
+	balancer := func(pools []ants.PoolInfo) (idx int) {
+		for i := range pools {
+			if pools[i].Running <= pools[idx].Running {
+				idx = i
+			}
+		}
+		return
+	}
+	mp, err := ants.NewMultiPool(2, 5, ants.RoundRobin, ants.WithLoadBalancer(balancer))
+	require.NoError(t, err)
+	require.ErrorIs(t, mp.Resize(0), ants.ErrInvalidMultiPoolSize)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, mp.Submit(longRunningFunc))
+	}
+	require.EqualValues(t, 0, mp.Free())
+
+	// Scale up, the new pools are created with the original size.
+	require.NoError(t, mp.Resize(4))
+	require.EqualValues(t, 4, mp.Size())
+	require.EqualValues(t, 20, mp.Cap())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, mp.Submit(longRunningFunc))
+	}
+	require.EqualValues(t, 20, mp.Running())
+	for i := 0; i < 4; i++ {
+		n, _ := mp.RunningByIndex(i)
+		require.EqualValues(t, 5, n)
+	}
+
+	// A submitter blocked on a removed pool is routed to the remaining pools.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mp.Submit(demoFunc)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	require.EqualValues(t, 1, mp.Waiting())
+
+	// Scale down, the removed pools are drained while the running tasks are allowed to complete.
+	require.NoError(t, mp.Resize(1))
+	require.EqualValues(t, 1, mp.Size())
+	require.EqualValues(t, 5, mp.Cap())
+	_, err = mp.RunningByIndex(1)
+	require.ErrorIs(t, err, ants.ErrInvalidPoolIndex)
+
+	atomic.StoreInt32(&stopLongRunningFunc, 1)
+	require.NoError(t, <-errCh)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	require.ErrorIs(t, mp.Resize(2), ants.ErrPoolClosed)
+	atomic.StoreInt32(&stopLongRunningFunc, 0)
+
+	mp.Reboot()
 	mp.Tune(10)
+	require.NoError(t, mp.Resize(3))
+	require.EqualValues(t, 30, mp.Cap())
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+}
+
+func TestMultiPoolReleaseDrainingPools(t *testing.T) {
+	// Each multi-pool runs a quick task on the first pool and a blocked one on the second pool,
+	// which is removed by Resize.
+	block := make(chan struct{})
+	task := func(arg any) {
+		if arg.(bool) {
+			<-block
+		}
+	}
+	type multiPool interface {
+		Resize(int) error
+		ReleaseTimeout(time.Duration) error
+	}
+	newMultiPools := map[string]func() (submit func(bool) error, mp multiPool){
+		"MultiPool": func() (func(bool) error, multiPool) {
+			mp, err := ants.NewMultiPool(2, 1, ants.RoundRobin)
+			require.NoError(t, err)
+			return func(blocked bool) error { return mp.Submit(func() { task(blocked) }) }, mp
+		},
+		"MultiPoolWithFunc": func() (func(bool) error, multiPool) {
+			mp, err := ants.NewMultiPoolWithFunc(2, 1, task, ants.RoundRobin)
+			require.NoError(t, err)
+			return func(blocked bool) error { return mp.Invoke(blocked) }, mp
+		},
+		"MultiPoolWithFuncGeneric": func() (func(bool) error, multiPool) {
+			mp, err := ants.NewMultiPoolWithFuncGeneric(2, 1, func(blocked bool) { task(blocked) }, ants.RoundRobin)
+			require.NoError(t, err)
+			return mp.Invoke, mp
+		},
+	}
+	for name, newMultiPool := range newMultiPools {
+		newMultiPool := newMultiPool
+		t.Run(name, func(t *testing.T) {
+			submit, mp := newMultiPool()
+			require.NoError(t, submit(false))
+			require.NoError(t, submit(true))
+			require.NoError(t, mp.Resize(1))
+
+			// The task still running on the removed pool holds up the release.
+			err := mp.ReleaseTimeout(100 * time.Millisecond)
+			require.ErrorContains(t, err, "draining pool 0")
+			require.ErrorContains(t, err, ants.ErrTimeout.Error())
+		})
+	}
+	close(block)
+}
+
+func TestMultiPoolResizeConcurrently(t *testing.T) {
+	var sum int32
+	mpf, err := ants.NewMultiPoolWithFunc(4, 10, func(i any) {
+		atomic.AddInt32(&sum, i.(int32))
+	}, ants.RoundRobin)
+	require.NoError(t, err)
+	mpg, err := ants.NewMultiPoolWithFuncGeneric(4, 10, func(i int32) {
+		atomic.AddInt32(&sum, i)
+	}, ants.LeastTasks)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				require.NoError(t, mpf.Invoke(int32(1)))
+				require.NoError(t, mpg.Invoke(int32(1)))
+			}
+		}()
+	}
+	for i := 1; i <= 20; i++ {
+		require.NoError(t, mpf.Resize(i%5+1))
+		require.NoError(t, mpg.Resize((i+2)%5+1))
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+
+	require.NoError(t, mpf.ReleaseTimeout(3*time.Second))
+	require.NoError(t, mpg.ReleaseTimeout(3*time.Second))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&sum) == 20000 }, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestMultiPoolWithSpecs(t *testing.T) {
+	_, err := ants.NewMultiPoolWithSpecs(nil, ants.WeightedRoundRobin)
+	require.ErrorIs(t, err, ants.ErrInvalidMultiPoolSize)
+	_, err = ants.NewMultiPoolWithSpecs([]ants.PoolSpec{{Size: 10}}, 0)
+	require.ErrorIs(t, err, ants.ErrInvalidLoadBalancingStrategy)
+	_, err = ants.NewMultiPoolWithSpecs([]ants.PoolSpec{{Size: 10, Options: []ants.Option{ants.WithExpiryDuration(-1)}}}, ants.RoundRobin)
+	require.ErrorIs(t, err, ants.ErrInvalidPoolExpiry)
+
+	// A small high-priority lane next to a large bulk lane.
+	specs := []ants.PoolSpec{
+		{Size: 2, Weight: 1},
+		{Size: 10, Weight: 3, Options: []ants.Option{ants.WithExpiryDuration(time.Minute)}},
+	}
+	mp, err := ants.NewMultiPoolWithSpecs(specs, ants.WeightedRoundRobin, ants.WithNonblocking(true))
+	require.NoError(t, err)
+	require.EqualValues(t, 12, mp.Cap())
+	for i := 0; i < 8; i++ {
+		require.NoError(t, mp.Submit(longRunningFunc))
+	}
+	n, _ := mp.RunningByIndex(0)
+	require.EqualValues(t, 2, n)
+	n, _ = mp.RunningByIndex(1)
+	require.EqualValues(t, 6, n)
+
+	// The new pools are instantiated with the specs in rotation.
+	require.NoError(t, mp.Resize(4))
+	require.EqualValues(t, 24, mp.Cap())
+	n, _ = mp.FreeByIndex(2)
+	require.EqualValues(t, 2, n)
+	n, _ = mp.FreeByIndex(3)
+	require.EqualValues(t, 10, n)
+	atomic.StoreInt32(&stopLongRunningFunc, 1)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	atomic.StoreInt32(&stopLongRunningFunc, 0)
+
+	ch := make(chan struct{})
+	mpf, err := ants.NewMultiPoolWithFuncSpecs(specs, longRunningPoolFunc, ants.WeightedRoundRobin)
+	require.NoError(t, err)
+	mpg, err := ants.NewMultiPoolWithFuncGenericSpecs(specs, longRunningPoolFuncCh, ants.WeightedRoundRobin)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, mpf.Invoke(ch))
+		require.NoError(t, mpg.Invoke(ch))
+	}
+	n, _ = mpf.RunningByIndex(0)
+	require.EqualValues(t, 1, n)
+	n, _ = mpf.RunningByIndex(1)
+	require.EqualValues(t, 3, n)
+	n, _ = mpg.RunningByIndex(0)
+	require.EqualValues(t, 1, n)
+	n, _ = mpg.RunningByIndex(1)
+	require.EqualValues(t, 3, n)
+	close(ch)
+	require.NoError(t, mpf.ReleaseTimeout(3*time.Second))
+	require.NoError(t, mpg.ReleaseTimeout(3*time.Second))
+
+	// The selection doesn't cost in proportion to the weights, however large they are.
+	specs = []ants.PoolSpec{{Size: 10, Weight: 1}, {Size: 10, Weight: 1 << 30}}
+	mp, err = ants.NewMultiPoolWithSpecs(specs, ants.WeightedRoundRobin, ants.WithNonblocking(true))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, mp.Submit(longRunningFunc))
+	}
+	n, _ = mp.RunningByIndex(1)
+	require.EqualValues(t, 10, n)
+	require.NoError(t, mp.Resize(3))
+	atomic.StoreInt32(&stopLongRunningFunc, 1)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	atomic.StoreInt32(&stopLongRunningFunc, 0)
+}
+
+func TestMultiPoolSpillover(t *testing.T) {
+	mp, err := ants.NewMultiPool(2, 1, ants.RoundRobin, ants.WithSpillover(true))
+	require.NoError(t, err)
+
+	ch0, ch1 := make(chan struct{}), make(chan struct{})
+	require.NoError(t, mp.Submit(func() { <-ch0 }))
+	require.NoError(t, mp.Submit(func() { <-ch1 }))
+	require.EqualValues(t, 0, mp.Free())
+
+	// The next task is bound for the first pool in rotation, but it's served
+	// by the second pool as soon as the second pool frees up a worker.
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mp.Submit(func() { close(done) })
+	}()
+	require.Eventually(t, func() bool { return mp.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+	close(ch1)
+	require.NoError(t, <-errCh)
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the blocked task should have been served by the idle pool")
+	}
+	require.EqualValues(t, 0, mp.Waiting())
+	n, _ := mp.RunningByIndex(0)
+	require.EqualValues(t, 1, n)
+
+	// The task is spilled over to the other pool without blocking.
+	ch1 = make(chan struct{})
+	require.NoError(t, mp.Submit(func() { <-ch1 }))
+	require.EqualValues(t, 0, mp.Free())
+
+	// The blocked submitters are woken up when the multi-pool is released.
+	go func() {
+		errCh <- mp.Submit(demoFunc)
+	}()
+	require.Eventually(t, func() bool { return mp.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+	close(ch0)
+	close(ch1)
+	require.NoError(t, <-errCh)
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	require.ErrorIs(t, mp.Submit(demoFunc), ants.ErrPoolClosed)
+}
+
+func TestMultiPoolSpilloverOverload(t *testing.T) {
+	ch := make(chan struct{})
+	mpf, err := ants.NewMultiPoolWithFunc(2, 1, longRunningPoolFunc, ants.RoundRobin,
+		ants.WithSpillover(true), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	require.NoError(t, mpf.Invoke(ch))
+	require.NoError(t, mpf.Invoke(ch))
+	require.ErrorIs(t, mpf.Invoke(ch), ants.ErrPoolOverload)
+	close(ch)
+	require.NoError(t, mpf.ReleaseTimeout(3*time.Second))
+
+	ch = make(chan struct{})
+	mpg, err := ants.NewMultiPoolWithFuncGeneric(2, 1, longRunningPoolFuncCh, ants.LeastTasks,
+		ants.WithSpillover(true), ants.WithMaxBlockingTasks(1))
+	require.NoError(t, err)
+	require.NoError(t, mpg.Invoke(ch))
+	require.NoError(t, mpg.Invoke(ch))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- mpg.Invoke(ch)
+	}()
+	require.Eventually(t, func() bool { return mpg.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, mpg.Invoke(ch), ants.ErrPoolOverload)
+	close(ch)
+	require.NoError(t, <-errCh)
+	require.NoError(t, mpg.ReleaseTimeout(3*time.Second))
+
+	// The blocked submitters get ErrPoolClosed once the multi-pool is released.
+	ch = make(chan struct{})
+	mpg, err = ants.NewMultiPoolWithFuncGeneric(1, 1, longRunningPoolFuncCh, ants.RoundRobin, ants.WithSpillover(true))
+	require.NoError(t, err)
+	require.NoError(t, mpg.Invoke(ch))
+	go func() {
+		errCh <- mpg.Invoke(ch)
+	}()
+	require.Eventually(t, func() bool { return mpg.Waiting() == 1 }, time.Second, 10*time.Millisecond)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(ch)
+	}()
+	require.NoError(t, mpg.ReleaseTimeout(3*time.Second))
+	require.ErrorIs(t, <-errCh, ants.ErrPoolClosed)
+}
+
+func TestMultiPoolSpilloverConcurrently(t *testing.T) {
+	var sum int32
+	mp, err := ants.NewMultiPool(4, 2, ants.RoundRobin, ants.WithSpillover(true))
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				require.NoError(t, mp.Submit(func() {
+					atomic.AddInt32(&sum, 1)
+				}))
+			}
+		}()
+	}
+	for i := 1; i <= 10; i++ {
+		require.NoError(t, mp.Resize(i%4+1))
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+	require.NoError(t, mp.ReleaseTimeout(3*time.Second))
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&sum) == 10000 }, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestSubmitErr(t *testing.T) {
+	type failure struct {
+		err  error
+		info ants.TaskInfo
+	}
+	failures := make(chan failure, 100)
+	errFoo := errors.New("foo")
+	p, err := ants.NewPool(10, ants.WithErrorHandler(func(err error, info ants.TaskInfo) {
+		failures <- failure{err, info}
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	for i := 0; i < 100; i++ {
+		i := i
+		require.NoError(t, p.SubmitErr(func() error {
+			if i%2 == 0 {
+				return fmt.Errorf("task %d: %w", i, errFoo)
+			}
+			return nil
+		}))
+	}
+	err = p.Wait()
+	require.ErrorIs(t, err, errFoo)
+	require.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 50)
+	require.Len(t, failures, 50)
+	ids := make(map[uint64]struct{})
+	for i := 0; i < 50; i++ {
+		f := <-failures
+		require.ErrorIs(t, f.err, errFoo)
+		ids[f.info.ID] = struct{}{}
+	}
+	require.Len(t, ids, 50)
+	require.EqualValues(t, 50, p.Stats().Failed)
+
+	// The collected errors are discarded once they are returned.
+	require.NoError(t, p.Wait())
+
+	// A panicking task doesn't block Wait().
+	require.NoError(t, p.SubmitErr(func() error {
+		panic("oops")
+	}))
+	require.NoError(t, p.Wait())
+	require.Eventually(t, func() bool { return p.Stats().Panicked == 1 }, time.Second, 10*time.Millisecond)
+
+	// A task failed to be submitted doesn't block Wait().
+	np, err := ants.NewPool(1, ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer np.Release()
+	ch := make(chan struct{})
+	require.NoError(t, np.SubmitErr(func() error {
+		<-ch
+		return errFoo
+	}))
+	require.ErrorIs(t, np.SubmitErr(func() error { return nil }), ants.ErrPoolOverload)
+	close(ch)
+	require.ErrorIs(t, np.Wait(), errFoo)
+	require.EqualValues(t, 1, np.Stats().Failed)
+}
+
+func TestPoolWithFuncGenericErr(t *testing.T) {
+	_, err := ants.NewPoolWithFuncGenericErr[int](10, nil)
+	require.ErrorIs(t, err, ants.ErrLackPoolFunc)
+	_, err = ants.NewPoolWithFuncGenericErr(10, func(int) error { return nil }, ants.WithExpiryDuration(-1))
+	require.ErrorIs(t, err, ants.ErrInvalidPoolExpiry)
+
+	var (
+		mu     sync.Mutex
+		failed []int
+	)
+	p, err := ants.NewPoolWithFuncGenericErr(10, func(i int) error {
+		if i%10 == 0 {
+			return fmt.Errorf("task %d failed", i)
+		}
+		return nil
+	}, ants.WithErrorHandler(func(err error, info ants.TaskInfo) {
+		mu.Lock()
+		failed = append(failed, info.Arg.(int))
+		mu.Unlock()
+	}), ants.WithMaxBlockingTasks(1000))
+	require.NoError(t, err)
+	defer p.Release()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Invoke(i))
+	}
+	err = p.Wait()
+	require.Error(t, err)
+	require.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 10)
+	require.Len(t, failed, 10)
+	require.EqualValues(t, 10, p.Stats().Failed)
+	require.NoError(t, p.Wait())
+
+	p.Release()
+	require.ErrorIs(t, p.Invoke(0), ants.ErrPoolClosed)
+	require.NoError(t, p.Wait())
+
+	// The info passed to the ErrorHandler is the one of the task reported by InFlight().
+	ch := make(chan struct{})
+	infos := make(chan ants.TaskInfo, 1)
+	p, err = ants.NewPoolWithFuncGenericErr(1, func(i int) error {
+		<-ch
+		return fmt.Errorf("task %d failed", i)
+	}, ants.WithTaskTracking(true), ants.WithErrorHandler(func(_ error, info ants.TaskInfo) {
+		infos <- info
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Invoke(7))
+	var running []ants.TaskInfo
+	require.Eventually(t, func() bool {
+		running = p.InFlight()
+		return len(running) == 1
+	}, time.Second, time.Millisecond)
+	close(ch)
+	info := <-infos
+	require.NotZero(t, info.ID)
+	require.False(t, info.SubmitTime.IsZero())
+	require.Equal(t, running[0].ID, info.ID)
+	require.Equal(t, running[0].SubmitTime, info.SubmitTime)
+	require.EqualValues(t, 7, info.Arg)
+	require.Error(t, p.Wait())
+}
+
+func TestGroup(t *testing.T) {
+	p, err := ants.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	g, ctx := ants.NewGroup(context.Background(), p)
+	var n int32
+	for i := 0; i < 100; i++ {
+		g.Go(func() error {
+			atomic.AddInt32(&n, 1)
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait())
+	require.EqualValues(t, 100, n)
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+
+	// The first error cancels the context and is returned by Wait().
+	errFoo, errBar := errors.New("foo"), errors.New("bar")
+	g, ctx = ants.NewGroup(context.Background(), p)
+	g.Go(func() error {
+		return errFoo
+	})
+	<-ctx.Done()
+	require.ErrorIs(t, context.Cause(ctx), errFoo)
+	g.Go(func() error {
+		return errBar
+	})
+	require.ErrorIs(t, g.Wait(), errFoo)
+
+	// TryGo fails if there is no available worker at once.
+	ch := make(chan struct{})
+	g, _ = ants.NewGroup(context.Background(), p)
+	for i := 0; i < 10; i++ {
+		require.True(t, g.TryGo(func() error {
+			<-ch
+			return nil
+		}))
+	}
+	require.False(t, g.TryGo(func() error { return nil }))
+	close(ch)
+	require.NoError(t, g.Wait())
+
+	// The submission error is treated as the error of the function.
+	p.Release()
+	g, ctx = ants.NewGroup(context.Background(), p)
+	g.Go(func() error { return nil })
+	require.ErrorIs(t, g.Wait(), ants.ErrPoolClosed)
+	require.ErrorIs(t, context.Cause(ctx), ants.ErrPoolClosed)
+	require.False(t, g.TryGo(func() error { return nil }))
+	require.ErrorIs(t, g.Wait(), ants.ErrPoolClosed)
+}
+
+func TestSubmitWithRetry(t *testing.T) {
+	errFoo, errBar := errors.New("foo"), errors.New("bar")
+	var (
+		mu    sync.Mutex
+		final []error
+	)
+	p, err := ants.NewPool(1, ants.WithErrorHandler(func(err error, _ ants.TaskInfo) {
+		mu.Lock()
+		final = append(final, err)
+		mu.Unlock()
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	// The task succeeds after two failures.
+	var attempts int32
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			return errFoo
+		}
+		return nil
+	}, ants.RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, Jitter: 0.5}))
+	require.NoError(t, p.Wait())
+	require.EqualValues(t, 3, attempts)
+	require.EqualValues(t, 2, p.Stats().Retried)
+	require.EqualValues(t, 0, p.Stats().Failed)
+
+	// The backing-off task doesn't occupy the only worker of the pool.
+	attempts = 0
+	ran := make(chan struct{})
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return errFoo
+		}
+		select {
+		case <-ran:
+			return nil
+		default:
+			return errBar
+		}
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: 200 * time.Millisecond}))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, p.Submit(func() { close(ran) }))
+	select {
+	case <-ran:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("the pool is occupied by the backing-off task")
+	}
+	require.NoError(t, p.Wait())
+	require.EqualValues(t, 2, attempts)
+
+	// The task gives up after MaxAttempts, only the last error is reported.
+	attempts = 0
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		return fmt.Errorf("attempt %d: %w", atomic.AddInt32(&attempts, 1), errFoo)
+	}, ants.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}))
+	err = p.Wait()
+	require.ErrorIs(t, err, errFoo)
+	require.ErrorContains(t, err, "attempt 3")
+	require.EqualValues(t, 3, attempts)
+	require.Len(t, final, 1)
+
+	// Non-retryable errors are not retried.
+	attempts = 0
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		atomic.AddInt32(&attempts, 1)
+		return errBar
+	}, ants.RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool {
+		return errors.Is(err, errFoo)
+	}}))
+	require.ErrorIs(t, p.Wait(), errBar)
+	require.EqualValues(t, 1, attempts)
+
+	// Panics are retried only if RetryOnPanic is set.
+	attempts = 0
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			panic("oops")
+		}
+		return nil
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryOnPanic: true}))
+	require.NoError(t, p.Wait())
+	require.EqualValues(t, 2, attempts)
+	require.EqualValues(t, 1, p.Stats().Panicked)
+
+	attempts = 0
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		atomic.AddInt32(&attempts, 1)
+		panic("oops")
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryOnPanic: true}))
+	require.ErrorIs(t, p.Wait(), ants.ErrTaskPanicked)
+	require.EqualValues(t, 2, attempts)
+
+	attempts = 0
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		atomic.AddInt32(&attempts, 1)
+		panic("oops")
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	require.NoError(t, p.Wait())
+	require.EqualValues(t, 1, attempts)
+
+	// The submission error of a retry is reported along with the last error of the task.
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		return errFoo
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: 100 * time.Millisecond}))
+	time.Sleep(50 * time.Millisecond)
+	p.Release()
+	err = p.Wait()
+	require.ErrorIs(t, err, errFoo)
+	require.ErrorIs(t, err, ants.ErrPoolClosed)
+	require.ErrorIs(t, p.SubmitWithRetry(func() error { return nil }, ants.RetryPolicy{}), ants.ErrPoolClosed)
+}
+
+func TestSubmitWithTimeout(t *testing.T) {
+	type overrun struct {
+		info    ants.TaskInfo
+		timeout time.Duration
+		stack   []byte
+	}
+	overruns := make(chan overrun, 10)
+	p, err := ants.NewPool(10, ants.WithTaskOverrunHandler(func(info ants.TaskInfo, timeout time.Duration, stack []byte) {
+		overruns <- overrun{info, timeout, stack}
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	// The task returns once its context is done.
+	ch := make(chan error, 1)
+	require.NoError(t, p.SubmitWithTimeout(50*time.Millisecond, func(ctx context.Context) {
+		<-ctx.Done()
+		ch <- ctx.Err()
+	}))
+	require.ErrorIs(t, <-ch, context.DeadlineExceeded)
+
+	// The task returning in time is not reported.
+	require.NoError(t, p.SubmitWithTimeout(time.Second, func(ctx context.Context) {
+		ch <- ctx.Err()
+	}))
+	require.NoError(t, <-ch)
+
+	// The task ignoring its context is reported with its stack trace.
+	release := make(chan struct{})
+	require.NoError(t, p.SubmitWithTimeout(50*time.Millisecond, func(context.Context) {
+		overrunTask(release)
+	}))
+	select {
+	case o := <-overruns:
+		require.NotZero(t, o.info.ID)
+		require.Equal(t, 50*time.Millisecond, o.timeout)
+		require.Contains(t, string(o.stack), "overrunTask")
+	case <-time.After(time.Second):
+		t.Fatal("the overrun task is not reported")
+	}
+	require.EqualValues(t, 1, p.Stats().Overrun)
+	require.Equal(t, 1, p.Running())
+	close(release)
+	require.Eventually(t, func() bool { return p.Free() == p.Cap() }, time.Second, 10*time.Millisecond)
+	require.Len(t, overruns, 0)
+
+	p.Release()
+	require.ErrorIs(t, p.SubmitWithTimeout(time.Second, func(context.Context) {}), ants.ErrPoolClosed)
+
+	// The goroutine stacks are dumped at most once per second for the overrunning tasks.
+	clock := antstest.NewFakeClock(time.Now())
+	p, err = ants.NewPool(10, ants.WithClock(clock), ants.WithTaskOverrunHandler(func(info ants.TaskInfo, timeout time.Duration, stack []byte) {
+		overruns <- overrun{info, timeout, stack}
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+	clock.BlockUntil(2)
+	release = make(chan struct{})
+	defer close(release)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.SubmitWithTimeout(time.Minute, func(context.Context) {
+			overrunTask(release)
+		}))
+	}
+	// Every task waits on the timer of its context and the one of the overrun.
+	clock.BlockUntil(6)
+	clock.Advance(time.Minute + time.Second)
+	var stacks int
+	for i := 0; i < 2; i++ {
+		if o := <-overruns; o.stack != nil {
+			require.Contains(t, string(o.stack), "overrunTask")
+			stacks++
+		}
+	}
+	require.Equal(t, 1, stacks)
+	require.EqualValues(t, 2, p.Stats().Overrun)
+	require.NoError(t, p.SubmitWithTimeout(time.Minute, func(context.Context) {
+		overrunTask(release)
+	}))
+	clock.BlockUntil(4)
+	clock.Advance(time.Minute + time.Second)
+	require.Contains(t, string((<-overruns).stack), "overrunTask")
+}
+
+func overrunTask(release chan struct{}) {
+	<-release
+}
+
+func TestSlowTaskWatchdog(t *testing.T) {
+	type slowTask struct {
+		info    ants.TaskInfo
+		elapsed time.Duration
+		stack   []byte
+	}
+	slow := make(chan slowTask, 10)
+	p, err := ants.NewPool(10, ants.WithSlowTaskWatchdog(100*time.Millisecond, func(info ants.TaskInfo, elapsed time.Duration, stack []byte) {
+		slow <- slowTask{info, elapsed, stack}
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+	require.Zero(t, p.Stats().OldestRunning)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.SubmitTask(func() {}, ants.WithTaskName("fast")))
+	}
+	release := make(chan struct{})
+	labels := map[string]string{"kind": "stuck"}
+	require.NoError(t, p.SubmitTask(func() {
+		stuckTask(release)
+	}, ants.WithTaskName("stuck"), ants.WithTaskLabels(labels)))
+
+	select {
+	case st := <-slow:
+		require.NotZero(t, st.info.ID)
+		require.Equal(t, "stuck", st.info.Name)
+		require.Equal(t, labels, st.info.Labels)
+		require.GreaterOrEqual(t, st.elapsed, 100*time.Millisecond)
+		require.Contains(t, string(st.stack), "stuckTask")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the stuck task is not reported")
+	}
+	require.GreaterOrEqual(t, p.Stats().OldestRunning, 100*time.Millisecond)
+
+	// The stuck task is reported only once.
+	time.Sleep(time.Second)
+	require.Len(t, slow, 0)
+
+	close(release)
+	require.Eventually(t, func() bool { return p.Stats().OldestRunning == 0 }, time.Second, 10*time.Millisecond)
+
+	// The tasks of pools with functions are watched as well.
+	pf, err := ants.NewPoolWithFunc(10, func(arg any) {
+		stuckTask(arg.(chan struct{}))
+	}, ants.WithSlowTaskWatchdog(100*time.Millisecond, func(info ants.TaskInfo, elapsed time.Duration, stack []byte) {
+		slow <- slowTask{info, elapsed, stack}
+	}))
+	require.NoError(t, err)
+	defer pf.Release()
+	release = make(chan struct{})
+	require.NoError(t, pf.Invoke(release))
+	select {
+	case st := <-slow:
+		require.Contains(t, string(st.stack), "stuckTask")
+	case <-time.After(3 * time.Second):
+		t.Fatal("the stuck task is not reported")
+	}
+	close(release)
+
+	p.Release()
+	require.ErrorIs(t, p.SubmitTask(func() {}), ants.ErrPoolClosed)
+}
+
+func stuckTask(release chan struct{}) {
+	<-release
+}
+
+func TestInFlight(t *testing.T) {
+	for _, preAlloc := range []bool{false, true} {
+		p, err := ants.NewPool(10, ants.WithPreAlloc(preAlloc), ants.WithTaskTracking(true))
+		require.NoError(t, err)
+
+		release := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(5)
+		for i := 0; i < 3; i++ {
+			require.NoError(t, p.SubmitTask(func() {
+				wg.Done()
+				<-release
+			}, ants.WithTaskName(fmt.Sprintf("task-%d", i)), ants.WithTaskLabels(map[string]string{"i": fmt.Sprint(i)})))
+		}
+		require.NoError(t, p.Submit(func() {
+			wg.Done()
+			<-release
+		}))
+		require.NoError(t, p.SubmitWeighted(2, func() {
+			wg.Done()
+			<-release
+		}))
+		wg.Wait()
+
+		// The tasks submitted without descriptions are identified as well.
+		tasks := p.InFlight()
+		require.Len(t, tasks, 5)
+		var (
+			named int
+			ids   = make(map[uint64]struct{})
+			gids  = make(map[uint64]struct{})
+		)
+		for _, task := range tasks {
+			require.NotZero(t, task.ID)
+			require.False(t, task.SubmitTime.IsZero())
+			require.False(t, task.StartTime.IsZero())
+			require.False(t, task.StartTime.Before(task.SubmitTime))
+			require.NotZero(t, task.GoroutineID)
+			ids[task.ID] = struct{}{}
+			gids[task.GoroutineID] = struct{}{}
+			if task.Name != "" {
+				named++
+				require.Equal(t, "task-"+task.Labels["i"], task.Name)
+			}
+		}
+		require.Equal(t, 3, named)
+		require.Len(t, ids, 5)
+		require.Len(t, gids, 5)
+		require.Empty(t, p.IdleWorkers())
+
+		close(release)
+		require.Eventually(t, func() bool { return len(p.IdleWorkers()) == 5 }, time.Second, 10*time.Millisecond)
+		require.Empty(t, p.InFlight())
+		for _, w := range p.IdleWorkers() {
+			require.Contains(t, gids, w.GoroutineID)
+			require.False(t, w.LastUsed.IsZero())
+		}
+		p.Release()
+	}
+
+	// So are the tasks invoked on the pools with functions.
+	release := make(chan struct{})
+	pf, err := ants.NewPoolWithFunc(1, func(any) { <-release }, ants.WithTaskTracking(true))
+	require.NoError(t, err)
+	defer pf.Release()
+	pg, err := ants.NewPoolWithFuncGeneric(1, func(int) { <-release }, ants.WithTaskTracking(true))
+	require.NoError(t, err)
+	defer pg.Release()
+	require.NoError(t, pf.Invoke(1))
+	require.NoError(t, pg.Invoke(1))
+	for _, p := range []interface{ InFlight() []ants.TaskInfo }{pf, pg} {
+		var tasks []ants.TaskInfo
+		require.Eventually(t, func() bool {
+			tasks = p.InFlight()
+			return len(tasks) == 1
+		}, time.Second, time.Millisecond)
+		require.NotZero(t, tasks[0].ID)
+		require.False(t, tasks[0].SubmitTime.IsZero())
+		require.NotZero(t, tasks[0].GoroutineID)
+	}
+	close(release)
+
+	// Without WithTaskTracking, the tasks are tracked since the pool is inspected for the first time.
+	p, err := ants.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+	block := make(chan struct{})
+	defer close(block)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	require.NoError(t, p.Submit(func() {
+		wg.Done()
+		<-block
+	}))
+	wg.Wait()
+	require.Empty(t, p.InFlight())
+	require.NoError(t, p.Submit(func() { <-block }))
+	var tasks []ants.TaskInfo
+	require.Eventually(t, func() bool {
+		tasks = p.InFlight()
+		return len(tasks) == 1
+	}, time.Second, time.Millisecond)
+	require.NotZero(t, tasks[0].ID)
+	require.NotZero(t, tasks[0].GoroutineID)
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) records() (records []map[string]any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimSpace(b.buf.Bytes()), []byte("\n")) {
+		var r map[string]any
+		if json.Unmarshal(line, &r) == nil {
+			records = append(records, r)
+		}
+	}
+	return
+}
+
+func (b *syncBuffer) find(msg string) map[string]any {
+	for _, r := range b.records() {
+		if r[slog.MessageKey] == msg {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestSlogger(t *testing.T) {
+	buf := &syncBuffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p, err := ants.NewPool(1, ants.WithSlogger(logger), ants.WithExpiryDuration(10*time.Millisecond))
+	require.NoError(t, err)
+
+	// Worker panics are logged at the error level with the stack in a single record.
+	require.NoError(t, p.Submit(func() {
+		panic("oops")
+	}))
+	require.Eventually(t, func() bool { return buf.find("worker exits from panic") != nil }, time.Second, 10*time.Millisecond)
+	r := buf.find("worker exits from panic")
+	require.Equal(t, "ERROR", r[slog.LevelKey])
+	require.Equal(t, "oops", r["panic"])
+	require.Contains(t, r["stack"], "goroutine")
+
+	// Purge cycles are logged at the debug level.
+	require.NoError(t, p.Submit(func() {}))
+	require.Eventually(t, func() bool {
+		for _, r := range buf.records() {
+			if r[slog.MessageKey] == "purged stale workers" && r["purged"] == float64(1) {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "DEBUG", buf.find("purged stale workers")[slog.LevelKey])
+
+	// Releases, reboots and release failures are logged as well.
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	require.Equal(t, "INFO", buf.find("pool released")[slog.LevelKey])
+	p.Reboot()
+	require.Equal(t, "INFO", buf.find("pool rebooted")[slog.LevelKey])
+
+	ch := make(chan struct{})
+	defer close(ch)
+	require.NoError(t, p.Submit(func() { <-ch }))
+	require.ErrorIs(t, p.ReleaseTimeout(10*time.Millisecond), ants.ErrTimeout)
+	r = buf.find("pool release timed out")
+	require.NotNil(t, r)
+	require.Equal(t, "WARN", r[slog.LevelKey])
+	require.EqualValues(t, 1, r["running"])
+}
+
+type printfLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *printfLogger) Printf(format string, args ...any) {
+	l.mu.Lock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+	l.mu.Unlock()
+}
+
+func TestLoggerHandler(t *testing.T) {
+	// The Printf-only Logger only receives the errors, and the panics are written in the plain format.
+	l := &printfLogger{}
+	p, err := ants.NewPool(1, ants.WithLogger(l))
+	require.NoError(t, err)
+	require.NoError(t, p.Submit(func() {
+		panic("oops")
+	}))
+	require.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return len(l.lines) == 1
+	}, time.Second, 10*time.Millisecond)
+	p.Release()
+	l.mu.Lock()
+	require.Len(t, l.lines, 1)
+	require.True(t, strings.HasPrefix(l.lines[0], "worker exits from panic: oops\ngoroutine "))
+	l.mu.Unlock()
+
+	// The adapter formats the attributes and groups as key=value.
+	l = &printfLogger{}
+	logger := slog.New(ants.NewLoggerHandler(l, slog.LevelInfo)).With("pool", "foo").WithGroup("g")
+	logger.Debug("dropped")
+	logger.Info("event", slog.Int("n", 1), slog.Group("sub", slog.String("k", "v")))
+	require.Equal(t, []string{"event pool=foo g.n=1 g.sub.k=v"}, l.lines)
+}
+
+func TestPanicHandlerV2(t *testing.T) {
+	panics := make(chan ants.PanicInfo, 1)
+	var legacy int32
+	p, err := ants.NewPool(10, ants.WithName("foo"), ants.WithPanicHandlerV2(func(info ants.PanicInfo) {
+		panics <- info
+	}), ants.WithPanicHandler(func(any) {
+		atomic.AddInt32(&legacy, 1)
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	labels := map[string]string{"k": "v"}
+	require.NoError(t, p.SubmitTask(func() {
+		panic("oops")
+	}, ants.WithTaskName("bar"), ants.WithTaskLabels(labels)))
+	info := <-panics
+	require.Equal(t, "oops", info.Value)
+	require.Contains(t, string(info.Stack), "TestPanicHandlerV2")
+	require.Equal(t, "foo", info.Pool)
+	require.NotZero(t, info.Task.ID)
+	require.Equal(t, "bar", info.Task.Name)
+	require.Equal(t, labels, info.Task.Labels)
+	require.Nil(t, info.Task.Arg)
+	require.False(t, info.Time.IsZero())
+	require.Zero(t, atomic.LoadInt32(&legacy))
+
+	// The argument of the task is reported for the pools with functions.
+	pf, err := ants.NewPoolWithFunc(10, func(arg any) {
+		panic(arg)
+	}, ants.WithPanicHandlerV2(func(info ants.PanicInfo) {
+		panics <- info
+	}))
+	require.NoError(t, err)
+	defer pf.Release()
+	require.NoError(t, pf.Invoke("baz"))
+	info = <-panics
+	require.Equal(t, "baz", info.Value)
+	require.Equal(t, "baz", info.Task.Arg)
+	require.Empty(t, info.Pool)
+
+	pfg, err := ants.NewPoolWithFuncGeneric(10, func(arg int) {
+		panic(arg)
+	}, ants.WithPanicHandlerV2(func(info ants.PanicInfo) {
+		panics <- info
+	}))
+	require.NoError(t, err)
+	defer pfg.Release()
+	require.NoError(t, pfg.Invoke(42))
+	info = <-panics
+	require.Equal(t, 42, info.Task.Arg)
+	require.EqualValues(t, 1, pfg.Stats().Panicked)
+}
+
+func TestPanicCircuitBreaker(t *testing.T) {
+	panics := make(chan struct{}, 10)
+	p, err := ants.NewPool(10, ants.WithPanicCircuitBreaker(3, time.Minute, 100*time.Millisecond),
+		ants.WithPanicHandler(func(any) { panics <- struct{}{} }))
+	require.NoError(t, err)
+	defer p.Release()
+
+	panicky := func() { panic("oops") }
+	// The breaker stays closed until the panics exceed the threshold.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, p.Submit(panicky))
+		<-panics
+	}
+	require.NoError(t, p.Submit(func() {}))
+	require.NoError(t, p.Submit(panicky))
+	<-panics
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrCircuitOpen)
+	require.ErrorIs(t, p.SubmitErr(func() error { return nil }), ants.ErrCircuitOpen)
+	require.NoError(t, p.Wait())
+
+	// Only one probe is let through after the cooldown.
+	time.Sleep(100 * time.Millisecond)
+	ch := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-ch }))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrCircuitOpen)
+	close(ch)
+	require.Eventually(t, func() bool { return p.Submit(func() {}) == nil }, time.Second, 10*time.Millisecond)
+
+	// A panicking probe opens the breaker again.
+	for i := 0; i < 4; i++ {
+		require.NoError(t, p.Submit(panicky))
+		<-panics
+	}
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrCircuitOpen)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, p.Submit(panicky))
+	<-panics
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrCircuitOpen)
+
+	// A probe that hangs is given up after another cooldown.
+	time.Sleep(100 * time.Millisecond)
+	hang := make(chan struct{})
+	defer close(hang)
+	require.NoError(t, p.Submit(func() { <-hang }))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrCircuitOpen)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, p.Submit(func() {}))
+	require.Eventually(t, func() bool { return p.Submit(func() {}) == nil }, time.Second, 10*time.Millisecond)
+
+	// Panics spread out of the window don't open the breaker.
+	pf, err := ants.NewPoolWithFunc(10, func(any) { panic("oops") },
+		ants.WithPanicCircuitBreaker(1, 50*time.Millisecond, time.Minute),
+		ants.WithPanicHandler(func(any) { panics <- struct{}{} }))
+	require.NoError(t, err)
+	defer pf.Release()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, pf.Invoke(i))
+		<-panics
+		time.Sleep(60 * time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, pf.Invoke(i))
+		<-panics
+	}
+	require.ErrorIs(t, pf.Invoke(0), ants.ErrCircuitOpen)
+}
+
+func TestRateLimit(t *testing.T) {
+	// Tasks exceeding the rate limit are rejected at once in nonblocking mode.
+	p, err := ants.NewPool(100, ants.WithRateLimit(10, 5), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer p.Release()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() {}))
+	}
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrRateLimited)
+	require.EqualValues(t, 1, p.Stats().RateLimited)
+	require.EqualValues(t, 0, p.Stats().Overloaded)
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, p.Submit(func() {}))
+
+	// Tasks wait for the rate limit in blocking mode.
+	p, err = ants.NewPool(100, ants.WithRateLimit(20, 1))
+	require.NoError(t, err)
+	defer p.Release()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() {}))
+	}
+	require.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	require.EqualValues(t, 0, p.Stats().RateLimited)
+
+	// MaxBlockingTasks limits the callers waiting for the rate limit.
+	p, err = ants.NewPool(100, ants.WithRateLimit(5, 1), ants.WithMaxBlockingTasks(1))
+	require.NoError(t, err)
+	defer p.Release()
+	require.NoError(t, p.Submit(func() {}))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.Submit(func() {})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrRateLimited)
+	require.NoError(t, <-errCh)
+	require.EqualValues(t, 1, p.Stats().RateLimited)
+
+	// The rejections due to the capacity are counted separately.
+	p, err = ants.NewPool(1, ants.WithRateLimit(1000, 10), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer p.Release()
+	ch := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-ch }))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolOverload)
+	close(ch)
+	require.EqualValues(t, 1, p.Stats().Overloaded)
+	require.EqualValues(t, 0, p.Stats().RateLimited)
+
+	// The pools with functions are rate limited as well.
+	pf, err := ants.NewPoolWithFuncGeneric(10, func(int) {}, ants.WithRateLimit(1, 1), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer pf.Release()
+	require.NoError(t, pf.Invoke(0))
+	require.ErrorIs(t, pf.Invoke(1), ants.ErrRateLimited)
+
+	// The callers waiting for the rate limit give up once the pool is released,
+	// and the tokens they have reserved are given back.
+	clock := antstest.NewFakeClock(time.Now())
+	p, err = ants.NewPool(10, ants.WithClock(clock), ants.WithRateLimit(1, 1))
+	require.NoError(t, err)
+	defer p.Release()
+	clock.BlockUntil(2)
+	require.NoError(t, p.Submit(func() {}))
+	go func() {
+		errCh <- p.Submit(func() {})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	select {
+	case err = <-errCh:
+		require.ErrorIs(t, err, ants.ErrPoolClosed)
+	case <-time.After(time.Second):
+		t.Fatal("the caller waiting for the rate limit is not woken up by Release")
+	}
+	require.Zero(t, p.Waiting())
+	p.Reboot()
+	clock.Advance(time.Second)
+	go func() {
+		errCh <- p.Submit(func() {})
+	}()
+	select {
+	case err = <-errCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the token reserved by the caller is not given back")
+	}
+}
+
+func TestSubmitWeighted(t *testing.T) {
+	p, err := ants.NewPool(10)
+	require.NoError(t, err)
+	defer p.Release()
+
+	require.ErrorIs(t, p.SubmitWeighted(0, func() {}), ants.ErrInvalidTaskWeight)
+	require.ErrorIs(t, p.SubmitWeighted(11, func() {}), ants.ErrInvalidTaskWeight)
+
+	// A heavy task waits until the light tasks give back enough capacity units.
+	light := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() { <-light }))
+	}
+	var heavyDone atomic.Bool
+	heavy := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.SubmitWeighted(8, func() {
+			heavyDone.Store(true)
+			<-heavy
+		})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	// The light tasks submitted later don't bypass the heavy one even if they fit.
+	var lateDone atomic.Int32
+	lateErrs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			lateErrs <- p.Submit(func() {
+				lateDone.Add(1)
+				<-heavy
+			})
+		}()
+	}
+	require.Eventually(t, func() bool { return p.Waiting() == 4 }, time.Second, time.Millisecond)
+	require.False(t, heavyDone.Load())
+	require.EqualValues(t, 0, lateDone.Load())
+
+	close(light)
+	require.NoError(t, <-errCh)
+	require.Eventually(t, heavyDone.Load, time.Second, time.Millisecond)
+	// 8 units are taken by the heavy task, so two of the light ones can run along with it.
+	require.Eventually(t, func() bool { return lateDone.Load() == 2 }, time.Second, time.Millisecond)
+	require.EqualValues(t, 1, p.Waiting())
+	close(heavy)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, <-lateErrs)
+	}
+	require.Eventually(t, func() bool { return lateDone.Load() == 3 }, time.Second, time.Millisecond)
+
+	// Weighted tasks are rejected at once in nonblocking mode.
+	p, err = ants.NewPool(4, ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer p.Release()
+	ch := make(chan struct{})
+	require.NoError(t, p.SubmitWeighted(3, func() { <-ch }))
+	require.ErrorIs(t, p.SubmitWeighted(2, func() {}), ants.ErrPoolOverload)
+	require.NoError(t, p.Submit(func() { <-ch }))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolOverload)
+	close(ch)
+
+	// The callers waiting for capacity units are woken up when the pool is released.
+	p, err = ants.NewPool(4)
+	require.NoError(t, err)
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, p.SubmitWeighted(4, func() { <-block }))
+	go func() {
+		errCh <- p.SubmitWeighted(2, func() {})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	p.Release()
+	require.ErrorIs(t, <-errCh, ants.ErrPoolClosed)
+}
+
+func TestSubmitForTenant(t *testing.T) {
+	// The tasks of a tenant are limited by its quota without holding up the others.
+	p, err := ants.NewPool(10, ants.WithTenantQuota("a", ants.TenantQuota{MaxConcurrency: 2}))
+	require.NoError(t, err)
+	defer p.Release()
+	ch := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.SubmitForTenant("a", func() { <-ch }))
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.SubmitForTenant("a", func() {})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, p.SubmitForTenant("b", func() { <-ch }))
+	require.NoError(t, p.Submit(func() { <-ch }))
+	stats := p.TenantStats()
+	require.EqualValues(t, ants.TenantStats{Running: 2, Waiting: 1, Admitted: 2}, stats["a"])
+	require.EqualValues(t, ants.TenantStats{Running: 1, Admitted: 1}, stats["b"])
+	require.EqualValues(t, ants.TenantStats{Running: 1, Admitted: 1}, stats[""])
+	close(ch)
+	require.NoError(t, <-errCh)
+	require.Eventually(t, func() bool { return p.TenantStats()["a"].Running == 0 }, time.Second, time.Millisecond)
+	require.EqualValues(t, 3, p.TenantStats()["a"].Admitted)
+	require.NotContains(t, p.TenantStats(), "b")
+
+	// The tenants without quotas don't pile up however many of them have come and gone.
+	var done sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		done.Add(1)
+		require.NoError(t, p.SubmitForTenant(strconv.Itoa(i), done.Done))
+	}
+	done.Wait()
+	require.Eventually(t, func() bool { return len(p.TenantStats()) == 2 }, time.Second, time.Millisecond)
+	require.Contains(t, p.TenantStats(), "a")
+	require.Contains(t, p.TenantStats(), "")
+
+	// The capacity units reserved for a tenant are off-limits to the others.
+	p, err = ants.NewPool(4, ants.WithNonblocking(true), ants.WithTenantQuota("r", ants.TenantQuota{Reserved: 2}))
+	require.NoError(t, err)
+	defer p.Release()
+	block := make(chan struct{})
+	defer close(block)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.Submit(func() { <-block }))
+	}
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolOverload)
+	require.ErrorIs(t, p.SubmitForTenant("x", func() {}), ants.ErrPoolOverload)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, p.SubmitForTenant("r", func() { <-block }))
+	}
+	require.ErrorIs(t, p.SubmitForTenant("r", func() {}), ants.ErrPoolOverload)
+	require.EqualValues(t, 1, p.TenantStats()[""].Rejected)
+	require.EqualValues(t, 1, p.TenantStats()["r"].Rejected)
+	// The tenant without a quota is evicted as soon as it's idle.
+	require.NotContains(t, p.TenantStats(), "x")
+
+	// The tenants are served in proportion to their weights when the pool is saturated.
+	p, err = ants.NewPool(1,
+		ants.WithTenantQuota("a", ants.TenantQuota{Weight: 2}),
+		ants.WithTenantQuota("b", ants.TenantQuota{Weight: 1}))
+	require.NoError(t, err)
+	defer p.Release()
+	hold := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-hold }))
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	var wg sync.WaitGroup
+	submit := func(tenant string, n int) {
+		for i := 0; i < n; i++ {
+			waiting := p.Waiting()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = p.SubmitForTenant(tenant, func() {
+					mu.Lock()
+					order = append(order, tenant)
+					mu.Unlock()
+				})
+			}()
+			require.Eventually(t, func() bool { return p.Waiting() == waiting+1 }, time.Second, time.Millisecond)
+		}
+	}
+	submit("b", 4)
+	submit("a", 6)
+	close(hold)
+	wg.Wait()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(order) == 10
+	}, time.Second, time.Millisecond)
+	require.EqualValues(t, []string{"a", "b", "a", "a", "b", "a", "a", "b", "a", "b"}, order)
+}
+
+func TestChildPool(t *testing.T) {
+	parent, err := ants.NewPool(4)
+	require.NoError(t, err)
+	defer parent.Release()
+	c1, err := ants.NewChildPool(parent, 3, ants.WithName("c1"), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer c1.Release()
+	c2, err := ants.NewChildPool(parent, 3, ants.WithName("c2"), ants.WithNonblocking(true))
+	require.NoError(t, err)
+	defer c2.Release()
+
+	// The concurrency of a child is capped by its own limit.
+	ch := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, c1.Submit(func() { <-ch }))
+	}
+	require.ErrorIs(t, c1.Submit(func() {}), ants.ErrPoolOverload)
+	require.EqualValues(t, 1, c1.Stats().Overloaded)
+
+	// The children never run more tasks than the capacity of the parent altogether.
+	require.NoError(t, c2.Submit(func() { <-ch }))
+	require.ErrorIs(t, c2.Submit(func() {}), ants.ErrPoolOverload)
+	require.EqualValues(t, 1, c2.Stats().Overloaded)
+	require.EqualValues(t, 3, parent.TenantStats()["c1"].Running)
+	require.EqualValues(t, 1, parent.TenantStats()["c2"].Running)
+
+	// The tasks of the parent wait for the capacity units borrowed by the children.
+	var done atomic.Bool
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- parent.Submit(func() { done.Store(true) })
+	}()
+	require.Eventually(t, func() bool { return parent.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.False(t, done.Load())
+	close(ch)
+	require.NoError(t, <-errCh)
+	require.Eventually(t, done.Load, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		stats := parent.TenantStats()
+		return stats["c1"].Running == 0 && stats["c2"].Running == 0
+	}, time.Second, time.Millisecond)
+
+	// The tenant of a child is evicted from the parent once the child is released and its tasks are done.
+	ch = make(chan struct{})
+	require.NoError(t, c1.Submit(func() { <-ch }))
+	c1.Release()
+	require.Contains(t, parent.TenantStats(), "c1")
+	close(ch)
+	require.Eventually(t, func() bool {
+		_, ok := parent.TenantStats()["c1"]
+		return !ok
+	}, time.Second, time.Millisecond)
+	require.Contains(t, parent.TenantStats(), "c2")
+
+	// A child blocked on the parent is woken up once the parent is released.
+	c3, err := ants.NewChildPool(parent, 0)
+	require.NoError(t, err)
+	defer c3.Release()
+	block := make(chan struct{})
+	defer close(block)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, c3.Submit(func() { <-block }))
+	}
+	// The child without a name borrows on behalf of a tenant of its own.
+	require.Zero(t, parent.TenantStats()[""].Running)
+	go func() {
+		errCh <- c3.Submit(func() {})
+	}()
+	require.Eventually(t, func() bool { return parent.Waiting() == 1 }, time.Second, time.Millisecond)
+	parent.Release()
+	require.ErrorIs(t, <-errCh, ants.ErrPoolClosed)
+	require.ErrorIs(t, c3.Submit(func() {}), ants.ErrPoolClosed)
+	_, err = ants.NewChildPool(parent, 1)
+	require.ErrorIs(t, err, ants.ErrPoolClosed)
+}
+
+func TestSharedRuntime(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	var slow atomic.Int32
+	pools := make([]*ants.Pool, 32)
+	for i := range pools {
+		p, err := ants.NewPool(10,
+			ants.WithSharedRuntime(true),
+			ants.WithExpiryDuration(time.Duration(50+i)*time.Millisecond),
+			ants.WithSlowTaskWatchdog(100*time.Millisecond, func(ants.TaskInfo, time.Duration, []byte) {
+				slow.Add(1)
+			}))
+		require.NoError(t, err)
+		pools[i] = p
+	}
+	// One clock goroutine and one scavenger goroutine serve all pools.
+	require.LessOrEqual(t, runtime.NumGoroutine(), before+2)
+
+	for _, p := range pools {
+		require.NoError(t, p.Submit(func() { time.Sleep(600 * time.Millisecond) }))
+	}
+	// The slow tasks are reported by the shared clock, and the workers are purged by the shared scavenger.
+	require.Eventually(t, func() bool { return slow.Load() == int32(len(pools)) }, 2*time.Second, 10*time.Millisecond)
+	for _, p := range pools {
+		require.Eventually(t, func() bool { return p.Running() == 0 }, 2*time.Second, 10*time.Millisecond)
+	}
+
+	for _, p := range pools {
+		require.NoError(t, p.ReleaseTimeout(time.Second))
+	}
+	pools[0].Reboot()
+	require.NoError(t, pools[0].Submit(func() {}))
+	require.Eventually(t, func() bool { return pools[0].Running() == 0 }, time.Second, 10*time.Millisecond)
+	pools[0].Release()
+
+	// The shared goroutines exit once there is no pool left.
+	// Poll it in place as require.Eventually runs the condition in another goroutine.
+	for deadline := time.Now().Add(2 * time.Second); runtime.NumGoroutine() > before && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func TestWithClock(t *testing.T) {
+	clock := antstest.NewFakeClock(time.Now())
+	var slow atomic.Int32
+	p, err := ants.NewPool(10,
+		ants.WithClock(clock),
+		ants.WithExpiryDuration(time.Minute),
+		ants.WithSlowTaskWatchdog(time.Minute, func(ants.TaskInfo, time.Duration, []byte) {
+			slow.Add(1)
+		}))
+	require.NoError(t, err)
+	defer p.Release()
+	// Wait for the tickers of the scavenger and the clock of the pool.
+	clock.BlockUntil(2)
+
+	// The idle workers are purged once the clock passes the expiry.
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() {}))
+	}
+	require.Greater(t, p.Running(), 0)
+	require.Eventually(t, func() bool { return len(p.IdleWorkers()) == p.Running() }, time.Second, time.Millisecond)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return p.Running() == 0 }, time.Second, time.Millisecond)
+
+	// The slow tasks are reported by the clock of the pool.
+	ch := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-ch }))
+	require.Eventually(t, func() bool { return len(p.InFlight()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(30 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 0, slow.Load())
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return slow.Load() == 1 }, time.Second, time.Millisecond)
+	close(ch)
+
+	// The tasks start at the time of the clock, not the time kept by the pool,
+	// which lags behind by up to 500 milliseconds.
+	clock.Advance(400 * time.Millisecond)
+	block := make(chan struct{})
+	require.NoError(t, p.Submit(func() { <-block }))
+	var running []ants.TaskInfo
+	require.Eventually(t, func() bool {
+		running = p.InFlight()
+		return len(running) == 1
+	}, time.Second, time.Millisecond)
+	require.True(t, running[0].StartTime.Equal(clock.Now()))
+	require.Zero(t, p.Stats().OldestRunning)
+	close(block)
+
+	// The retries are scheduled by the clock of the pool.
+	var attempts atomic.Int32
+	require.NoError(t, p.SubmitWithRetry(func() error {
+		if attempts.Add(1) == 1 {
+			return errors.New("foo")
+		}
+		return nil
+	}, ants.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour}))
+	require.Eventually(t, func() bool { return p.Stats().Retried == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	require.EqualValues(t, 1, attempts.Load())
+	clock.Advance(time.Hour)
+	require.NoError(t, p.Wait())
+	require.EqualValues(t, 2, attempts.Load())
+
+	// So are the timeouts of tasks.
+	errCh := make(chan error, 1)
+	require.NoError(t, p.SubmitWithTimeout(time.Hour, func(ctx context.Context) {
+		deadline, ok := ctx.Deadline()
+		if !ok || !deadline.Equal(clock.Now().Add(time.Hour)) {
+			errCh <- errors.New("unexpected deadline")
+			return
+		}
+		<-ctx.Done()
+		errCh <- ctx.Err()
+	}))
+	require.Eventually(t, func() bool { return len(p.InFlight()) == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	require.ErrorIs(t, <-errCh, context.DeadlineExceeded)
+}
+
+func TestFaultInjection(t *testing.T) {
+	// submitWithFaults submits n tasks one by one to the pool set up by newPool,
+	// and returns what happened to each of them.
+	submitWithFaults := func(n int, newPool func(ran func(int)) (submit func(i int) error, release func() error)) []string {
+		var mu sync.Mutex
+		outcomes := make([]string, n)
+		submit, release := newPool(func(i int) {
+			mu.Lock()
+			outcomes[i] = "ran"
+			mu.Unlock()
+		})
+		for i := 0; i < n; i++ {
+			if err := submit(i); err != nil {
+				require.ErrorIs(t, err, ants.ErrPoolOverload)
+				mu.Lock()
+				outcomes[i] = "rejected"
+				mu.Unlock()
+			}
+		}
+		require.NoError(t, release())
+		mu.Lock()
+		defer mu.Unlock()
+		for i := range outcomes {
+			if outcomes[i] == "" {
+				outcomes[i] = "panicked"
+			}
+		}
+		return outcomes
+	}
+	count := func(outcomes []string, outcome string) (n int) {
+		for _, o := range outcomes {
+			if o == outcome {
+				n++
+			}
+		}
+		return
+	}
+	newFaults := func() *ants.FaultInjection {
+		return &ants.FaultInjection{Seed: 42, RejectRate: 0.3, PanicRate: 0.2}
+	}
+
+	// The faults are reproducible with the same seed.
+	var panics atomic.Int32
+	poolOutcomes := func() []string {
+		p, err := ants.NewPool(200, ants.WithFaultInjection(newFaults()), ants.WithPanicHandlerV2(func(pi ants.PanicInfo) {
+			if pi.Value == ants.ErrInjectedPanic {
+				panics.Add(1)
+			}
+		}))
+		require.NoError(t, err)
+		return submitWithFaults(200, func(ran func(int)) (func(int) error, func() error) {
+			return func(i int) error {
+				return p.Submit(func() { ran(i) })
+			}, func() error { return p.ReleaseTimeout(time.Second) }
+		})
+	}
+	outcomes := poolOutcomes()
+	require.Equal(t, outcomes, poolOutcomes())
+	require.InDelta(t, 60, count(outcomes, "rejected"), 25)
+	require.InDelta(t, 28, count(outcomes, "panicked"), 20)
+	require.EqualValues(t, 2*count(outcomes, "panicked"), panics.Load())
+
+	// The pools with functions draw the faults in the same way.
+	pfOutcomes := func() []string {
+		return submitWithFaults(200, func(ran func(int)) (func(int) error, func() error) {
+			p, err := ants.NewPoolWithFuncGeneric(200, ran,
+				ants.WithFaultInjection(newFaults()), ants.WithPanicHandler(func(any) {}))
+			require.NoError(t, err)
+			return p.Invoke, func() error { return p.ReleaseTimeout(time.Second) }
+		})
+	}
+	require.Equal(t, outcomes, pfOutcomes())
+
+	// The multi-pools draw the faults of a submission once, no matter how many pools they try,
+	// so they meet the same faults as a pool.
+	mpOutcomes := func() []string {
+		mp, err := ants.NewMultiPool(4, 50, ants.RoundRobin,
+			ants.WithFaultInjection(newFaults()), ants.WithPanicHandler(func(any) {}))
+		require.NoError(t, err)
+		return submitWithFaults(200, func(ran func(int)) (func(int) error, func() error) {
+			return func(i int) error {
+				return mp.Submit(func() { ran(i) })
+			}, func() error { return mp.ReleaseTimeout(time.Second) }
+		})
+	}
+	require.Equal(t, outcomes, mpOutcomes())
+	mpfOutcomes := func() []string {
+		return submitWithFaults(200, func(ran func(int)) (func(int) error, func() error) {
+			mp, err := ants.NewMultiPoolWithFuncGeneric(4, 50, ran, ants.RoundRobin,
+				ants.WithFaultInjection(newFaults()), ants.WithPanicHandler(func(any) {}))
+			require.NoError(t, err)
+			return mp.Invoke, func() error { return mp.ReleaseTimeout(time.Second) }
+		})
+	}
+	require.Equal(t, outcomes, mpfOutcomes())
+
+	// The submissions and the tasks are delayed by the clock of the pool.
+	clock := antstest.NewFakeClock(time.Now())
+	p, err := ants.NewPool(10, ants.WithClock(clock), ants.WithTaskTracking(true),
+		ants.WithFaultInjection(&ants.FaultInjection{Seed: 1, QueueDelay: time.Hour, Latency: time.Hour}))
+	require.NoError(t, err)
+	defer p.Release()
+	clock.BlockUntil(2)
+	ran := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.Submit(func() { close(ran) })
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	clock.Advance(time.Hour)
+	require.NoError(t, <-errCh)
+	require.Eventually(t, func() bool { return len(p.InFlight()) == 1 }, time.Second, time.Millisecond)
+	select {
+	case <-ran:
+		t.Fatal("the task should be delayed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Hour)
+	<-ran
+}
+
+func TestWithLockFreeQueue(t *testing.T) {
+	_, err := ants.NewPool(-1, ants.WithLockFreeQueue(true))
+	require.ErrorIs(t, err, ants.ErrInvalidLockFreeQueueSize)
+	_, err = ants.NewPool(10, ants.WithLockFreeQueue(true), ants.WithWorkerQueue(ants.WorkerQueueLIFO))
+	require.ErrorIs(t, err, ants.ErrInvalidWorkerQueue)
+
+	p, err := ants.NewPool(10, ants.WithLockFreeQueue(true), ants.WithExpiryDuration(100*time.Millisecond))
+	require.NoError(t, err)
+	defer p.Release()
+
+	// The plain tasks and the weighted ones never take more capacity units than the pool has.
+	var (
+		wg       sync.WaitGroup
+		inUse    atomic.Int32
+		maxInUse atomic.Int32
+	)
+	run := func(weight int32) func() {
+		return func() {
+			defer wg.Done()
+			n := inUse.Add(weight)
+			for m := maxInUse.Load(); n > m && !maxInUse.CompareAndSwap(m, n); m = maxInUse.Load() {
+			}
+			runtime.Gosched()
+			inUse.Add(-weight)
+		}
+	}
+	var submitters sync.WaitGroup
+	errs := make(chan error, 8000)
+	for i := 0; i < 8; i++ {
+		submitters.Add(1)
+		go func(i int) {
+			defer submitters.Done()
+			for j := 0; j < 1000; j++ {
+				wg.Add(1)
+				var err error
+				if i == 0 && j%10 == 0 {
+					err = p.SubmitWeighted(4, run(4))
+				} else {
+					err = p.Submit(run(1))
+				}
+				if err != nil {
+					wg.Done()
+					errs <- err
+				}
+			}
+		}(i)
+	}
+	submitters.Wait()
+	wg.Wait()
+	require.Empty(t, errs)
+	require.LessOrEqual(t, maxInUse.Load(), int32(10))
+	require.EqualValues(t, 8000, p.TenantStats()[""].Admitted)
+	require.Eventually(t, func() bool { return p.TenantStats()[""].Running == 0 }, time.Second, time.Millisecond)
+
+	// The capacity can't be tuned.
+	p.Tune(20)
+	require.EqualValues(t, 10, p.Cap())
+
+	// The idle workers are still purged after they expire.
+	require.Eventually(t, func() bool { return p.Running() == 0 }, 3*time.Second, 10*time.Millisecond)
+
+	// The callers blocked on a full pool are admitted once the workers are put back.
+	ch := make(chan struct{})
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Submit(func() { <-ch }))
+	}
+	errCh := make(chan error, 5)
+	var done atomic.Int32
+	for i := 0; i < 5; i++ {
+		go func() {
+			errCh <- p.Submit(func() { done.Add(1) })
+		}()
+	}
+	require.Eventually(t, func() bool { return p.Waiting() == 5 }, time.Second, time.Millisecond)
+	close(ch)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, <-errCh)
+	}
+	require.Eventually(t, func() bool { return done.Load() == 5 }, time.Second, time.Millisecond)
+
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolClosed)
+}
+
+func TestWithShardedWorkers(t *testing.T) {
+	// The idle workers can't be kept in the shards and another kind of queue at once.
+	for _, opt := range []ants.Option{
+		ants.WithLockFreeQueue(true),
+		ants.WithPreAlloc(true),
+		ants.WithWorkerQueue(ants.WorkerQueueMinHeap),
+	} {
+		_, err := ants.NewPool(10, ants.WithShardedWorkers(true), opt)
+		require.ErrorIs(t, err, ants.ErrInvalidWorkerQueue)
+	}
+
+	p, err := ants.NewPool(10, ants.WithShardedWorkers(true), ants.WithExpiryDuration(100*time.Millisecond))
+	require.NoError(t, err)
+	defer p.Release()
+
+	// The tasks submitted concurrently never run on more workers than the pool has.
+	var (
+		wg      sync.WaitGroup
+		running atomic.Int32
+		maxRun  atomic.Int32
+	)
+	task := func() {
+		defer wg.Done()
+		n := running.Add(1)
+		for m := maxRun.Load(); n > m && !maxRun.CompareAndSwap(m, n); m = maxRun.Load() {
+		}
+		runtime.Gosched()
+		running.Add(-1)
+	}
+	var submitters sync.WaitGroup
+	errs := make(chan error, 8000)
+	for i := 0; i < 8; i++ {
+		submitters.Add(1)
+		go func() {
+			defer submitters.Done()
+			for j := 0; j < 1000; j++ {
+				wg.Add(1)
+				if err := p.Submit(task); err != nil {
+					wg.Done()
+					errs <- err
+				}
+			}
+		}()
+	}
+	submitters.Wait()
+	wg.Wait()
+	require.Empty(t, errs)
+	require.LessOrEqual(t, maxRun.Load(), int32(10))
+	require.LessOrEqual(t, p.Running(), 10)
+	require.EqualValues(t, 8000, p.TenantStats()[""].Admitted)
+
+	// Unlike the lock-free queue, the capacity can be tuned.
+	p.Tune(20)
+	require.EqualValues(t, 20, p.Cap())
+
+	// The idle workers in all shards are purged after they expire.
+	require.Eventually(t, func() bool { return p.Running() == 0 }, 3*time.Second, 10*time.Millisecond)
+
+	// An unlimited pool can be sharded as well.
+	p, err = ants.NewPool(-1, ants.WithShardedWorkers(true))
+	require.NoError(t, err)
+	var done atomic.Int32
+	for i := 0; i < 100; i++ {
+		require.NoError(t, p.Submit(func() { done.Add(1) }))
+	}
+	require.Eventually(t, func() bool { return done.Load() == 100 }, time.Second, time.Millisecond)
+	require.NoError(t, p.ReleaseTimeout(time.Second))
+}
+
+func TestWithWorkerQueue(t *testing.T) {
+	_, err := ants.NewPool(-1, ants.WithWorkerQueue(ants.WorkerQueueFIFO))
+	require.ErrorIs(t, err, ants.ErrInvalidWorkerQueue)
+	_, err = ants.NewPool(10, ants.WithWorkerQueue(ants.WorkerQueueKind(-1)))
+	require.ErrorIs(t, err, ants.ErrInvalidWorkerQueue)
+
+	// reused returns the goroutine of the worker reused after the workers are put back in order,
+	// and the goroutines of those workers in the same order.
+	reused := func(kind ants.WorkerQueueKind) (uint64, []uint64) {
+		p, err := ants.NewPool(3, ants.WithWorkerQueue(kind), ants.WithTaskTracking(true))
+		require.NoError(t, err)
+		defer p.Release()
+
+		release := make([]chan struct{}, 3)
+		for i := range release {
+			release[i] = make(chan struct{})
+			require.NoError(t, p.SubmitTask(func(ch chan struct{}) func() {
+				return func() { <-ch }
+			}(release[i]), ants.WithTaskName(fmt.Sprint(i))))
+		}
+		require.Eventually(t, func() bool { return len(p.InFlight()) == 3 }, time.Second, time.Millisecond)
+		gids := make([]uint64, 3)
+		for _, task := range p.InFlight() {
+			i, _ := strconv.Atoi(task.Name)
+			gids[i] = task.GoroutineID
+		}
+		for i, ch := range release {
+			close(ch)
+			require.Eventually(t, func() bool { return len(p.IdleWorkers()) == i+1 }, time.Second, time.Millisecond)
+		}
+
+		block := make(chan struct{})
+		defer close(block)
+		require.NoError(t, p.SubmitTask(func() { <-block }, ants.WithTaskName("next")))
+		require.Eventually(t, func() bool { return len(p.InFlight()) == 1 }, time.Second, time.Millisecond)
+		return p.InFlight()[0].GoroutineID, gids
+	}
+
+	gid, gids := reused(ants.WorkerQueueLIFO)
+	require.Equal(t, gids[2], gid, "LIFO should reuse the worker put back last")
+	gid, gids = reused(ants.WorkerQueueFIFO)
+	require.Equal(t, gids[0], gid, "FIFO should reuse the worker put back first")
+
+	// The idle workers in a min-heap expire like the others, and the capacity can be tuned.
+	p, err := ants.NewPool(10, ants.WithWorkerQueue(ants.WorkerQueueMinHeap), ants.WithExpiryDuration(100*time.Millisecond))
+	require.NoError(t, err)
+	defer p.Release()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		require.NoError(t, p.Submit(wg.Done))
+	}
+	wg.Wait()
+	p.Tune(20)
+	require.EqualValues(t, 20, p.Cap())
+	require.Eventually(t, func() bool { return p.Running() == 0 }, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestWithLocker(t *testing.T) {
+	lockers := []struct {
+		name      string
+		newLocker func() sync.Locker
+	}{
+		{"TicketLock", syncx.NewTicketLock},
+		{"MCSLock", syncx.NewMCSLock},
+		{"AdaptiveLock", syncx.NewAdaptiveLock},
+	}
+	for _, l := range lockers {
+		l := l
+		t.Run(l.name, func(t *testing.T) {
+			var created atomic.Int32
+			newLocker := func() sync.Locker {
+				created.Add(1)
+				return l.newLocker()
+			}
+
+			// The submitters outnumbering the workers are blocked on the lock and the cond built on it.
+			p, err := ants.NewPool(4, ants.WithLocker(newLocker))
+			require.NoError(t, err)
+			defer p.Release()
+			require.EqualValues(t, 1, created.Load())
+
+			var (
+				wg  sync.WaitGroup
+				ran atomic.Int32
+			)
+			errs := make(chan error, 1000)
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						if err := p.Submit(func() {
+							ran.Add(1)
+							runtime.Gosched()
+						}); err != nil {
+							errs <- err
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			require.Empty(t, errs)
+			require.Eventually(t, func() bool { return ran.Load() == 1000 }, time.Second, time.Millisecond)
+
+			// Every pool of a multi-pool has its own lock.
+			mp, err := ants.NewMultiPool(3, 4, ants.RoundRobin, ants.WithLocker(newLocker))
+			require.NoError(t, err)
+			defer mp.ReleaseTimeout(time.Second) //nolint:errcheck
+			require.EqualValues(t, 4, created.Load())
+		})
+	}
 }
 
 func TestRebootNewPoolCalc(t *testing.T) {
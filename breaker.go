@@ -0,0 +1,170 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	circuitClosed int32 = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker stops a pool from accepting tasks after the tasks keep panicking.
+//
+// The breaker opens once more than threshold panics occur within the window, then all
+// submissions fail with ErrCircuitOpen until the cooldown elapses. After that the breaker
+// is half-open and admits one task as the probe: the breaker closes if the probe returns
+// normally, or opens again if any task panics. A probe that hasn't returned within another
+// cooldown is given up, and the next task is admitted as the probe.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	state atomic.Int32
+
+	mu       sync.Mutex
+	panics   []time.Time // ring buffer of the times of the latest panics
+	head     int
+	openedAt time.Time
+	probedAt time.Time // the time the current probe was admitted, zero if there is no probe
+}
+
+func newCircuitBreaker(threshold int, window, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 || window <= 0 {
+		return nil
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		panics:    make([]time.Time, 0, threshold+1),
+	}
+}
+
+// admit reports whether a task can be submitted, probe is true if the task is the probe of the half-open breaker.
+func (b *circuitBreaker) admit(now time.Time) (probe bool, err error) {
+	if b.state.Load() == circuitClosed {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state.Load() {
+	case circuitOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false, ErrCircuitOpen
+		}
+		b.state.Store(circuitHalfOpen)
+	case circuitHalfOpen:
+	default:
+		return false, nil
+	}
+	if !b.probedAt.IsZero() && now.Sub(b.probedAt) < b.cooldown {
+		return false, ErrCircuitOpen
+	}
+	b.probedAt = now
+	return true, nil
+}
+
+// abortProbe lets another task be the probe after the probe failed to be submitted.
+func (b *circuitBreaker) abortProbe() {
+	b.mu.Lock()
+	b.probedAt = time.Time{}
+	b.mu.Unlock()
+}
+
+// probeSucceeded closes the breaker after the probe returns normally.
+func (b *circuitBreaker) probeSucceeded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state.Load() == circuitHalfOpen {
+		b.state.Store(circuitClosed)
+		b.panics = b.panics[:0]
+		b.head = 0
+	}
+	b.probedAt = time.Time{}
+}
+
+// recordPanic records a panic and reports whether it opens the breaker.
+func (b *circuitBreaker) recordPanic(now time.Time) (opened bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state.Load() != circuitClosed {
+		// Any panic during the half-open state means the tasks are still broken.
+		opened = b.state.Load() == circuitHalfOpen
+		b.state.Store(circuitOpen)
+		b.openedAt = now
+		b.probedAt = time.Time{}
+		return
+	}
+
+	if len(b.panics) < cap(b.panics) {
+		b.panics = append(b.panics, now)
+		if len(b.panics) < cap(b.panics) {
+			return false
+		}
+	} else {
+		b.panics[b.head] = now
+		b.head = (b.head + 1) % len(b.panics)
+	}
+	// The oldest one of the latest threshold+1 panics.
+	if now.Sub(b.panics[b.head]) > b.window {
+		return false
+	}
+	b.state.Store(circuitOpen)
+	b.openedAt = now
+	b.panics = b.panics[:0]
+	b.head = 0
+	return true
+}
+
+// admitTask checks the circuit breaker before retrieving a worker, the returned function
+// must be called with the retrieved worker, which is nil if the retrieval failed.
+func (p *poolCommon) admitTask() (settle func(w worker), err error) {
+	probe, err := p.breaker.admit(p.clock.Now())
+	if err != nil || !probe {
+		return nil, err
+	}
+	return func(w worker) {
+		if w != nil {
+			w.tracked().probe = true
+		} else {
+			p.breaker.abortProbe()
+		}
+	}, nil
+}
+
+// endTask marks the end of a task that returned normally on the worker goroutine.
+func (p *poolCommon) endTask(t *taskTracker) {
+	t.end()
+	if t.probe {
+		t.probe = false
+		p.breaker.probeSucceeded()
+	}
+}
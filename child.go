@@ -0,0 +1,126 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+// NewChildPool instantiates a Pool that borrows its capacity from the parent pool.
+//
+// Every task running in the child pool takes a capacity unit of the parent pool besides
+// its own, so the children of a parent never run more tasks than the capacity of the parent
+// altogether, while the concurrency of each child is further capped by its limit. The child
+// has its own workers, statistics and options, and it's released independently of the parent.
+//
+// The capacity units are borrowed on behalf of the tenant of the parent named after the child,
+// see WithName, so the children can be given quotas and weights by WithTenantQuota on the parent,
+// and their usage of the parent is reported by the TenantStats of the parent. A child without a name
+// borrows on behalf of a tenant of its own, which is not reported. The tenant is kept by the parent
+// until the child is released.
+// Submitting tasks to the child fails with ErrPoolClosed once the parent is closed.
+func NewChildPool(parent *Pool, limit int, options ...Option) (*Pool, error) {
+	if parent.IsClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	p, err := NewPool(limit, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	p.parent = parent.poolCommon
+	p.joinParent()
+	// The capacity units are borrowed from the parent with the lock held.
+	p.lockFree = false
+
+	return p, nil
+}
+
+// joinParent pins the tenant of the parent that the child borrows the capacity units on behalf of.
+func (p *poolCommon) joinParent() {
+	p.parent.lock.Lock()
+	defer p.parent.lock.Unlock()
+	switch {
+	case p.options.Name != "":
+		p.share = p.parent.tenantLocked(p.options.Name)
+	case p.share == nil:
+		// The tenant of its own is kept across reboots, as the tasks still running
+		// might give back the capacity units borrowed on its behalf.
+		p.share = newTenantState("", TenantQuota{})
+	}
+	p.share.pins++
+}
+
+// leaveParent unpins the tenant of the child that is released, the tenant is evicted
+// from the parent once the tasks of the child are done.
+func (p *poolCommon) leaveParent() {
+	p.parent.lock.Lock()
+	p.share.pins--
+	p.parent.evictLocked(p.share)
+	p.parent.lock.Unlock()
+}
+
+// borrowLocked borrows the capacity units reserved for the task of the tenant from the parent,
+// it must be called with p.lock held, which is released while blocking on the parent.
+// The reservation is given back if it fails to borrow.
+func (p *poolCommon) borrowLocked(ts *tenantState, weight int, block bool) (err error) {
+	if wait := block && !p.options.Nonblocking; wait {
+		p.lock.Unlock()
+		err = p.parent.borrow(p.share, weight, true)
+		p.lock.Lock()
+	} else {
+		err = p.parent.borrow(p.share, weight, false)
+	}
+	if err != nil {
+		p.releaseLocked(ts, weight)
+		p.signalLocked()
+		if err == ErrPoolOverload && block {
+			p.overloaded.Add(1)
+		}
+	}
+	return
+}
+
+// borrow takes the capacity units on behalf of the child pool.
+func (p *poolCommon) borrow(share *tenantState, weight int, block bool) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.reserveWeight(share, weight, block)
+}
+
+// repay gives back the capacity units borrowed by the child pool.
+func (p *poolCommon) repay(share *tenantState, weight int) {
+	p.lock.Lock()
+	p.releaseLocked(share, weight)
+	p.signalLocked()
+	p.lock.Unlock()
+}
+
+// unreserveLocked gives back the capacity units reserved for the task of the tenant,
+// along with the ones borrowed from the parent, it must be called with p.lock held.
+func (p *poolCommon) unreserveLocked(ts *tenantState, weight int) {
+	p.releaseLocked(ts, weight)
+	if p.parent != nil {
+		p.parent.repay(p.share, weight)
+	}
+}
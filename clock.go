@@ -0,0 +1,138 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time of a pool, see WithClock.
+//
+// The pool tells the time, expires and purges its workers, watches slow tasks, and schedules
+// the delayed tasks, e.g. retries and timeouts, all by its Clock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTicker returns a Ticker that ticks with the period d.
+	NewTicker(d time.Duration) Ticker
+
+	// AfterFunc calls f in its own goroutine after the duration d elapses.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Ticker is the ticker returned by Clock.NewTicker, like time.Ticker.
+type Ticker interface {
+	// Chan returns the channel on which the ticks are delivered.
+	Chan() <-chan time.Time
+
+	// Stop turns off the ticker.
+	Stop()
+}
+
+// Timer is the timer returned by Clock.AfterFunc, like time.Timer.
+type Timer interface {
+	// Stop prevents the timer from firing, it returns false if the timer has already fired or been stopped.
+	Stop() bool
+}
+
+// realClock is the Clock of the wall time.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) Chan() <-chan time.Time { return t.C }
+
+// sleep pauses the current goroutine for the duration d by the clock of the pool.
+func (p *poolCommon) sleep(d time.Duration) {
+	if _, ok := p.clock.(realClock); ok {
+		time.Sleep(d)
+		return
+	}
+	ch := make(chan struct{})
+	p.clock.AfterFunc(d, func() { close(ch) })
+	<-ch
+}
+
+// sleepUnlessClosed is like sleep but returns false as soon as the pool is closed.
+func (p *poolCommon) sleepUnlessClosed(d time.Duration) bool {
+	closed := p.ticktockCtx.Done()
+	if _, ok := p.clock.(realClock); ok {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return true
+		case <-closed:
+			return false
+		}
+	}
+	ch := make(chan struct{})
+	timer := p.clock.AfterFunc(d, func() { close(ch) })
+	select {
+	case <-ch:
+		return true
+	case <-closed:
+		timer.Stop()
+		return false
+	}
+}
+
+// withTimeout is like context.WithTimeout but the timeout elapses by the clock of the pool.
+func (p *poolCommon) withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := p.clock.(realClock); ok {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := p.clock.AfterFunc(timeout, cancel)
+	return &clockContext{Context: ctx, deadline: p.clock.Now().Add(timeout)}, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// clockContext is the context done at the deadline by a Clock other than the wall time.
+type clockContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockContext) Err() error {
+	if c.Context.Err() != nil {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
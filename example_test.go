@@ -23,6 +23,7 @@
 package ants_test
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -172,3 +173,29 @@ func ExampleMultiPoolWithFuncGeneric() {
 
 	// Output: The result is 499500
 }
+
+func ExampleGroup() {
+	pool, _ := ants.NewPool(10)
+	defer pool.Release()
+
+	var total int32
+	g, ctx := ants.NewGroup(context.Background(), pool)
+	for i := 0; i < 1000; i++ {
+		j := i
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			atomic.AddInt32(&total, int32(j))
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		fmt.Printf("The group failed: %v\n", err)
+		return
+	}
+
+	fmt.Printf("The result is %d\n", total)
+
+	// Output: The result is 499500
+}
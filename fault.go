@@ -0,0 +1,165 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultInjection describes the faults injected into pools for testing, see WithFaultInjection.
+//
+// The faults are drawn from a pseudo-random sequence seeded by Seed, one draw per submission,
+// so the same submissions made in the same order always meet the same faults. The pools set up
+// with the same FaultInjection share the sequence, while a multi-pool draws the faults itself and
+// hands them over to its pools.
+type FaultInjection struct {
+	// Seed is the seed of the pseudo-random sequence of faults.
+	Seed int64
+
+	// RejectRate is the fraction of submissions rejected with ErrPoolOverload.
+	RejectRate float64
+
+	// QueueDelay is the maximum delay of submissions before they retrieve workers,
+	// each submission is delayed by a random duration in [0, QueueDelay].
+	QueueDelay time.Duration
+
+	// Latency is the maximum latency added to tasks before they run,
+	// each task is delayed by a random duration in [0, Latency] on its worker.
+	Latency time.Duration
+
+	// PanicRate is the fraction of tasks that panic with ErrInjectedPanic instead of running.
+	PanicRate float64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// fault is the fault drawn for a submission.
+type fault struct {
+	reject  bool
+	delay   time.Duration
+	latency time.Duration
+	panic   bool
+}
+
+// draw returns the fault for the next submission.
+func (fi *FaultInjection) draw() (f fault) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+	if fi.rng == nil {
+		fi.rng = rand.New(rand.NewSource(fi.Seed))
+	}
+	// Always take the same number of values from the sequence to keep the draws aligned.
+	reject, delay, latency, panicked := fi.rng.Float64(), fi.rng.Float64(), fi.rng.Float64(), fi.rng.Float64()
+	f.reject = reject < fi.RejectRate
+	f.delay = time.Duration(delay * float64(fi.QueueDelay))
+	f.latency = time.Duration(latency * float64(fi.Latency))
+	f.panic = panicked < fi.PanicRate
+	return
+}
+
+// injectFault injects the fault of a submission, which is drawn here unless it's handed over by
+// the multi-pool owning this pool, the returned function must be called with the retrieved worker,
+// which is nil if the retrieval failed.
+func (p *poolCommon) injectFault(block bool, handed *fault) (settle func(w worker), err error) {
+	var f fault
+	if handed != nil {
+		f = *handed
+	} else {
+		f = p.options.FaultInjection.draw()
+	}
+	if f.reject {
+		if block {
+			p.overloaded.Add(1)
+		}
+		return nil, ErrPoolOverload
+	}
+	if f.delay > 0 && block {
+		p.addWaiting(1)
+		p.sleep(f.delay)
+		p.addWaiting(-1)
+		if p.IsClosed() {
+			return nil, ErrPoolClosed
+		}
+	}
+	if f.latency == 0 && !f.panic {
+		return nil, nil
+	}
+	return func(w worker) {
+		if w != nil {
+			w.tracked().fault = f
+		}
+	}, nil
+}
+
+// injectTaskFault injects the fault of the task that is about to run on the worker goroutine.
+func (p *poolCommon) injectTaskFault(t *taskTracker) {
+	f := t.fault
+	t.fault = fault{}
+	if f.latency > 0 {
+		p.sleep(f.latency)
+	}
+	if f.panic {
+		panic(ErrInjectedPanic)
+	}
+}
+
+// multiPoolFaults injects the faults of submissions to a multi-pool, once for each submission
+// rather than for every pool the multi-pool tries, and hands the faults of tasks over to the pools,
+// so a multi-pool meets the same faults as a pool with the same FaultInjection.
+type multiPoolFaults struct {
+	fi    *FaultInjection
+	clock Clock
+}
+
+func newMultiPoolFaults(opts *Options) *multiPoolFaults {
+	if opts.FaultInjection == nil {
+		return nil
+	}
+	mf := &multiPoolFaults{fi: opts.FaultInjection, clock: opts.Clock}
+	if mf.clock == nil {
+		mf.clock = realClock{}
+	}
+	return mf
+}
+
+// inject draws the fault for a submission and injects it, the returned fault is the rest of it
+// to be handed over to the pool that takes the submission. It's a no-op on a nil multiPoolFaults.
+func (mf *multiPoolFaults) inject() (*fault, error) {
+	if mf == nil {
+		return nil, nil
+	}
+	f := mf.fi.draw()
+	if f.reject {
+		return nil, ErrPoolOverload
+	}
+	if f.delay > 0 {
+		ch := make(chan struct{})
+		mf.clock.AfterFunc(f.delay, func() { close(ch) })
+		<-ch
+	}
+	f.reject, f.delay = false, 0
+	return &f, nil
+}
@@ -1,6 +1,6 @@
 module github.com/panjf2000/ants/v2
 
-go 1.18
+go 1.21
 
 require (
 	github.com/stretchr/testify v1.10.0
@@ -0,0 +1,99 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is a collection of tasks working on subtasks that are part of the same overall task,
+// it has the same semantics as errgroup.Group from golang.org/x/sync, except that the subtasks
+// are executed by the workers of a Pool instead of new goroutines.
+type Group struct {
+	pool   *Pool
+	cancel func(error)
+
+	wg sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a new Group backed by the pool and an associated Context derived from ctx.
+//
+// The derived Context is canceled the first time a function passed to Go
+// returns a non-nil error or the first time Wait returns, whichever occurs first.
+func NewGroup(ctx context.Context, pool *Pool) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{pool: pool, cancel: cancel}, ctx
+}
+
+// Go calls the given function on a worker of the pool, it blocks until there is
+// an available worker in the pool.
+//
+// The first call to return a non-nil error cancels the group's context, and its
+// error will be returned by Wait. An error that fails the submission, such as
+// ErrPoolClosed or ErrPoolOverload, is treated as the error of the function.
+func (g *Group) Go(f func() error) {
+	g.wg.Add(1)
+	if err := g.pool.Submit(g.wrap(f)); err != nil {
+		g.setErr(err)
+		g.wg.Done()
+	}
+}
+
+// TryGo calls the given function on a worker of the pool only if there is an
+// available worker in the pool at once, it reports whether the function was started.
+func (g *Group) TryGo(f func() error) bool {
+	g.wg.Add(1)
+	if err := g.pool.trySubmit(g.wrap(f), nil); err != nil {
+		g.wg.Done()
+		return false
+	}
+	return true
+}
+
+// Wait blocks until all function calls from the Go and TryGo have returned,
+// then returns the first non-nil error (if any) from them.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(g.err)
+	return g.err
+}
+
+func (g *Group) wrap(f func() error) func() {
+	return func() {
+		defer g.wg.Done()
+		if err := f(); err != nil {
+			g.setErr(err)
+		}
+	}
+}
+
+func (g *Group) setErr(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
@@ -0,0 +1,93 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+// acquireFast takes a capacity unit for a plain task without p.lock, it fails if the pool is full
+// or there are callers queued for the capacity units, which are not to be overtaken.
+func (p *poolCommon) acquireFast() bool {
+	capacity := int32(p.Cap())
+	for {
+		if p.queued.Load() > 0 {
+			return false
+		}
+		inUse := p.inUse.Load()
+		if capacity != -1 && inUse >= capacity {
+			return false
+		}
+		if p.inUse.CompareAndSwap(inUse, inUse+1) {
+			return true
+		}
+	}
+}
+
+// retrieveFast retrieves an idle worker for a plain task without p.lock, it returns nil if
+// the task has to take the slow path, i.e. the pool is full or there is no idle worker.
+func (p *poolCommon) retrieveFast() worker {
+	if !p.acquireFast() {
+		return nil
+	}
+	w := p.workers.detach()
+	if w == nil {
+		p.inUse.Add(-1)
+		p.wakeWaiters()
+		return nil
+	}
+	p.fastAdmitted.Add(1)
+	w.tracked().hold(nil, 1)
+	return w
+}
+
+// revertFast puts the worker that has run a task admitted by retrieveFast back into
+// the queue without p.lock.
+func (p *poolCommon) revertFast(w worker) bool {
+	t := w.tracked()
+	p.inUse.Add(-int32(t.weight))
+	t.weight = 0
+
+	if err := p.workers.insert(w); err != nil {
+		p.wakeWaiters()
+		return false
+	}
+	// The queue might have been reset by Release before the worker is put back,
+	// then reset it again to avoid memory leaks, the worker is finished as well.
+	// Issue: https://github.com/panjf2000/ants/issues/113
+	if p.IsClosed() {
+		p.workers.reset()
+		return true
+	}
+	p.wakeWaiters()
+	p.notifyIdle()
+
+	return true
+}
+
+// wakeWaiters notifies the callers blocked on the pool of an available worker or capacity unit
+// given back without p.lock, if there is any.
+//
+// The callers check the pool again after they're counted in p.queued or p.waiting, so they
+// never miss what is given back before they're seen here.
+func (p *poolCommon) wakeWaiters() {
+	if p.queued.Load() > 0 || p.Waiting() > 0 {
+		p.signal()
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -42,18 +43,429 @@ const (
 
 	// LeastTasks always selects the pool with the least number of pending tasks.
 	LeastTasks
+
+	// PowerOfTwoChoices samples two pools at random and selects the one with
+	// fewer running tasks, which avoids scanning all pools on every submission.
+	PowerOfTwoChoices
+
+	// LeastWaiting selects the pool with the lowest load, the load of a pool is
+	// the number of its running and waiting tasks relative to its capacity.
+	LeastWaiting
+
+	// WeightedRoundRobin distributes task to a list of pools in rotation,
+	// each pool receives a share of tasks proportional to its weight.
+	WeightedRoundRobin
 )
 
+func (lbs LoadBalancingStrategy) isValid() bool {
+	switch lbs {
+	case RoundRobin, LeastTasks, PowerOfTwoChoices, LeastWaiting, WeightedRoundRobin:
+		return true
+	}
+	return false
+}
+
+// PoolInfo is a snapshot of the state of a pool within a multi-pool.
+type PoolInfo struct {
+	// Running is the number of the currently running workers.
+	Running int
+
+	// Waiting is the number of the tasks blocked on submission.
+	Waiting int
+
+	// Free is the number of available workers, -1 indicates the pool is unlimited.
+	Free int
+
+	// Cap is the capacity of the pool, -1 indicates the pool is unlimited.
+	Cap int
+}
+
+// LoadBalancer is a custom load-balancing algorithm for multi-pools, it receives
+// the snapshots of all pools and returns the index of the selected pool.
+// An index out of range makes the multi-pool fall back to its LoadBalancingStrategy.
+type LoadBalancer func([]PoolInfo) int
+
+// PoolSpec specifies a pool within a multi-pool.
+type PoolSpec struct {
+	// Size is the capacity of the pool.
+	Size int
+
+	// Weight is the relative share of tasks that the pool receives under WeightedRoundRobin,
+	// a value less than 1 is treated as 1.
+	Weight int
+
+	// Options are applied to the pool after the options shared by all pools.
+	Options []Option
+}
+
+// normalizeSpecs returns a copy of specs with the weights normalized and the shared options merged.
+func normalizeSpecs(specs []PoolSpec, options []Option) []PoolSpec {
+	normalized := make([]PoolSpec, len(specs))
+	for i, spec := range specs {
+		if spec.Weight < 1 {
+			spec.Weight = 1
+		}
+		opts := make([]Option, 0, len(options)+len(spec.Options))
+		spec.Options = append(append(opts, options...), spec.Options...)
+		normalized[i] = spec
+	}
+	return normalized
+}
+
+// subPool is implemented by all kinds of pools that a multi-pool consists of.
+type subPool interface {
+	Running() int
+	Free() int
+	Waiting() int
+	Cap() int
+	Tune(int)
+	Release()
+	waitReleased(time.Duration) error
+}
+
+// drainingPools are the pools removed from a multi-pool by Resize, they're released but allowed to
+// complete the tasks running on them, and ReleaseTimeout of the multi-pool waits for them as well.
+// It's guarded by the mutex of the multi-pool.
+type drainingPools[P subPool] struct {
+	pools []P
+}
+
+// add releases the removed pools and keeps them until they're drained.
+func (dp *drainingPools[P]) add(removed []P) {
+	for _, pool := range removed {
+		pool.Release()
+	}
+	dp.pools = append(dp.pools, removed...)
+	dp.prune()
+}
+
+// prune drops the pools that have been drained.
+func (dp *drainingPools[P]) prune() {
+	pools := dp.pools[:0]
+	for _, pool := range dp.pools {
+		if pool.Running() > 0 {
+			pools = append(pools, pool)
+		}
+	}
+	clear(dp.pools[len(pools):])
+	dp.pools = pools
+}
+
+// list returns a copy of the pools that might be still running tasks.
+func (dp *drainingPools[P]) list() []P {
+	dp.prune()
+	return append([]P(nil), dp.pools...)
+}
+
+// poolSet is an immutable snapshot of the pools within a multi-pool.
+type poolSet[P subPool] struct {
+	pools []P
+
+	// specs are the specifications that the pools were instantiated with.
+	specs []PoolSpec
+
+	// schedule is the precomputed rounds of the smooth weighted round-robin, which spreads
+	// the selections of every pool evenly, and wrrIndex is the position in it, see nextWeighted.
+	schedule []int
+	wrrIndex atomic.Uint64
+}
+
+func newPoolSet[P subPool](pools []P, specs []PoolSpec) *poolSet[P] {
+	return &poolSet[P]{pools: pools, specs: specs, schedule: weightedSchedule(specs)}
+}
+
+// maxWeightedRounds caps the length of the weighted round-robin schedule,
+// the weights are scaled down proportionally to fit in it.
+const maxWeightedRounds = 1 << 12
+
+// weightedSchedule returns the rounds of the smooth weighted round-robin over the pools of the specs,
+// which repeat every sum of the weights reduced by their greatest common divisor.
+func weightedSchedule(specs []PoolSpec) []int {
+	weights := make([]int, len(specs))
+	d := 0
+	for i, spec := range specs {
+		weights[i] = spec.Weight
+		d = gcd(d, spec.Weight)
+	}
+	var sum float64
+	for i := range weights {
+		weights[i] /= d
+		sum += float64(weights[i])
+	}
+	scale := math.Min(1, maxWeightedRounds/sum)
+
+	total := 0
+	for i, w := range weights {
+		weights[i] = max(1, int(float64(w)*scale))
+		total += weights[i]
+	}
+	schedule := make([]int, total)
+	current := make([]int, len(weights))
+	for round := range schedule {
+		selected := 0
+		for i, w := range weights {
+			current[i] += w
+			if current[i] > current[selected] {
+				selected = i
+			}
+		}
+		current[selected] -= total
+		schedule[round] = selected
+	}
+	return schedule
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// nextWeighted returns the index of the pool selected by the smooth weighted round-robin.
+func (ps *poolSet[P]) nextWeighted() int {
+	return ps.schedule[(ps.wrrIndex.Add(1)-1)%uint64(len(ps.schedule))]
+}
+
+// next returns the index of the pool selected by the load-balancing strategy.
+func (ps *poolSet[P]) next(lbs LoadBalancingStrategy, index, seed *uint32) (idx int) {
+	pools := ps.pools
+	switch lbs {
+	case RoundRobin:
+		return int(atomic.AddUint32(index, 1) % uint32(len(pools)))
+	case LeastTasks:
+		leastTasks := 1<<31 - 1
+		for i, pool := range pools {
+			if n := pool.Running(); n < leastTasks {
+				leastTasks = n
+				idx = i
+			}
+		}
+		return
+	case PowerOfTwoChoices:
+		n := uint32(len(pools))
+		if n == 1 {
+			return 0
+		}
+		i, j := fastrandn(seed, n), fastrandn(seed, n-1)
+		if j >= i {
+			j++
+		}
+		if pools[j].Running() < pools[i].Running() {
+			return int(j)
+		}
+		return int(i)
+	case LeastWaiting:
+		leastLoad := math.MaxFloat64
+		for i, pool := range pools {
+			capacity := pool.Cap()
+			if capacity <= 0 {
+				capacity = math.MaxInt32
+			}
+			if load := float64(pool.Running()+pool.Waiting()) / float64(capacity); load < leastLoad {
+				leastLoad = load
+				idx = i
+			}
+		}
+		return
+	case WeightedRoundRobin:
+		return ps.nextWeighted()
+	}
+	return -1
+}
+
+// balance returns the index of the pool selected by the custom load balancer,
+// or -1 if there is no load balancer or the selected index is out of range.
+func (ps *poolSet[P]) balance(balancer LoadBalancer) int {
+	if balancer == nil {
+		return -1
+	}
+	infos := make([]PoolInfo, len(ps.pools))
+	for i, pool := range ps.pools {
+		infos[i] = PoolInfo{
+			Running: pool.Running(),
+			Waiting: pool.Waiting(),
+			Free:    pool.Free(),
+			Cap:     pool.Cap(),
+		}
+	}
+	if idx := balancer(infos); idx >= 0 && idx < len(ps.pools) {
+		return idx
+	}
+	return -1
+}
+
+// resize returns a copy of the pool set resized to size along with the removed pools.
+// The new pools are instantiated by newPool with the specs chosen from specs in rotation,
+// and tuned to the given size if it's positive.
+func (ps *poolSet[P]) resize(size int, specs []PoolSpec, tuned int, newPool func(PoolSpec) (P, error)) (*poolSet[P], []P, error) {
+	pools := make([]P, size)
+	copy(pools, ps.pools)
+	poolSpecs := make([]PoolSpec, size)
+	copy(poolSpecs, ps.specs)
+	n := len(ps.pools)
+	if size <= n {
+		return newPoolSet(pools, poolSpecs), ps.pools[size:], nil
+	}
+
+	for i := n; i < size; i++ {
+		spec := specs[i%len(specs)]
+		pool, err := newPool(spec)
+		if err != nil {
+			for _, p := range pools[n:i] {
+				p.Release()
+			}
+			return nil, nil, err
+		}
+		if tuned > 0 {
+			pool.Tune(tuned)
+		}
+		pools[i], poolSpecs[i] = pool, spec
+	}
+	return newPoolSet(pools, poolSpecs), nil, nil
+}
+
+// spillover serves the submitters blocked on a multi-pool with whichever pool frees up a worker first.
+type spillover struct {
+	lock sync.Mutex
+	cond *sync.Cond
+
+	// gen is increased every time a worker is freed up, so that a submitter
+	// can tell whether it has missed the notification before getting blocked.
+	gen uint64
+
+	// pending is the number of submitters that failed at their first attempt.
+	pending int32
+
+	// waiting is the number of submitters blocked on the multi-pool, protected by lock.
+	waiting int32
+
+	nonblocking      bool
+	maxBlockingTasks int
+}
+
+func newSpillover(opts *Options) *spillover {
+	s := &spillover{
+		nonblocking:      opts.Nonblocking,
+		maxBlockingTasks: opts.MaxBlockingTasks,
+	}
+	s.cond = sync.NewCond(&s.lock)
+	return s
+}
+
+// submit keeps calling try until it succeeds or fails with an error other than ErrPoolOverload,
+// try is supposed to attempt all pools without blocking.
+func (s *spillover) submit(isClosed func() bool, try func() error) error {
+	if isClosed() {
+		return ErrPoolClosed
+	}
+	if err := try(); err != ErrPoolOverload || s.nonblocking {
+		return err
+	}
+
+	// Register as pending before the next attempt, any worker freed up after the
+	// registration will be notified and the ones before it will be seen by the attempt.
+	atomic.AddInt32(&s.pending, 1)
+	defer atomic.AddInt32(&s.pending, -1)
+	for {
+		s.lock.Lock()
+		gen := s.gen
+		s.lock.Unlock()
+
+		if isClosed() {
+			return ErrPoolClosed
+		}
+		if err := try(); err != ErrPoolOverload {
+			return err
+		}
+
+		s.lock.Lock()
+		if s.gen == gen {
+			if s.maxBlockingTasks != 0 && int(s.waiting) >= s.maxBlockingTasks {
+				s.lock.Unlock()
+				return ErrPoolOverload
+			}
+			atomic.AddInt32(&s.waiting, 1)
+			s.cond.Wait()
+			atomic.AddInt32(&s.waiting, -1)
+		}
+		s.lock.Unlock()
+	}
+}
+
+// notify wakes up one of the blocked submitters, if any.
+func (s *spillover) notify() {
+	if atomic.LoadInt32(&s.pending) == 0 {
+		return
+	}
+	s.lock.Lock()
+	s.gen++
+	s.cond.Signal()
+	s.lock.Unlock()
+}
+
+// close wakes up all blocked submitters.
+func (s *spillover) close() {
+	s.lock.Lock()
+	s.gen++
+	s.cond.Broadcast()
+	s.lock.Unlock()
+}
+
+// Waiting returns the number of submitters blocked on the multi-pool.
+func (s *spillover) Waiting() int {
+	return int(atomic.LoadInt32(&s.waiting))
+}
+
+// trySpill attempts to submit the argument to the pools without blocking, starting from
+// the pool at idx and moving on to the next one if it's full or has been removed by Resize().
+func trySpill[P any, A any](pools []P, idx int, try func(P, A) error, arg A) error {
+	for i := range pools {
+		if err := try(pools[(idx+i)%len(pools)], arg); err != ErrPoolOverload && err != ErrPoolClosed {
+			return err
+		}
+	}
+	return ErrPoolOverload
+}
+
+// fastrandn returns a pseudo-random number in [0, n), it's safe for concurrent use.
+func fastrandn(seed *uint32, n uint32) uint32 {
+	// Mix the Weyl sequence with the finalizer of MurmurHash3.
+	x := atomic.AddUint32(seed, 0x9e3779b9)
+	x ^= x >> 16
+	x *= 0x85ebca6b
+	x ^= x >> 13
+	x *= 0xc2b2ae35
+	x ^= x >> 16
+	return uint32(uint64(x) * uint64(n) >> 32)
+}
+
 // MultiPool consists of multiple pools, from which you will benefit the
 // performance improvement on basis of the fine-grained locking that reduces
 // the lock contention.
 // MultiPool is a good fit for the scenario where you have a large number of
 // tasks to submit, and you don't want the single pool to be the bottleneck.
 type MultiPool struct {
-	pools []*Pool
-	index uint32
-	state int32
-	lbs   LoadBalancingStrategy
+	pools    atomic.Value // *poolSet[*Pool]
+	index    uint32
+	seed     uint32
+	state    int32
+	lbs      LoadBalancingStrategy
+	balancer LoadBalancer
+
+	// mu serializes the operations that replace the pool set.
+	mu    sync.Mutex
+	specs []PoolSpec
+	tuned int
+
+	// draining are the pools removed by Resize, which might be still running tasks.
+	draining drainingPools[*Pool]
+
+	// spill is not nil if the multi-pool is in spillover mode.
+	spill *spillover
+
+	// faults is not nil if the faults are injected into the multi-pool.
+	faults *multiPoolFaults
 }
 
 // NewMultiPool instantiates a MultiPool with a size of the pool list and a size
@@ -63,54 +475,105 @@ func NewMultiPool(size, sizePerPool int, lbs LoadBalancingStrategy, options ...O
 		return nil, ErrInvalidMultiPoolSize
 	}
 
-	if lbs != RoundRobin && lbs != LeastTasks {
+	specs := make([]PoolSpec, size)
+	for i := range specs {
+		specs[i].Size = sizePerPool
+	}
+	return NewMultiPoolWithSpecs(specs, lbs, options...)
+}
+
+// NewMultiPoolWithSpecs instantiates a MultiPool with a list of pool specs, each of which
+// specifies the size, weight and options of a pool, and the load-balancing strategy.
+// The given options are shared by all pools.
+func NewMultiPoolWithSpecs(specs []PoolSpec, lbs LoadBalancingStrategy, options ...Option) (*MultiPool, error) {
+	if len(specs) == 0 {
+		return nil, ErrInvalidMultiPoolSize
+	}
+
+	if !lbs.isValid() {
 		return nil, ErrInvalidLoadBalancingStrategy
 	}
-	pools := make([]*Pool, size)
-	for i := 0; i < size; i++ {
-		pool, err := NewPool(sizePerPool, options...)
+	opts := loadOptions(options...)
+	specs = normalizeSpecs(specs, options)
+	mp := &MultiPool{
+		index:    math.MaxUint32,
+		lbs:      lbs,
+		balancer: opts.LoadBalancer,
+		specs:    specs,
+	}
+	if opts.Spillover {
+		mp.spill = newSpillover(opts)
+	}
+	mp.faults = newMultiPoolFaults(opts)
+	pools := make([]*Pool, len(specs))
+	for i, spec := range specs {
+		pool, err := mp.newPool(spec)
 		if err != nil {
 			return nil, err
 		}
 		pools[i] = pool
 	}
-	return &MultiPool{pools: pools, index: math.MaxUint32, lbs: lbs}, nil
+	mp.pools.Store(newPoolSet(pools, specs))
+	return mp, nil
 }
 
-func (mp *MultiPool) next(lbs LoadBalancingStrategy) (idx int) {
-	switch lbs {
-	case RoundRobin:
-		return int(atomic.AddUint32(&mp.index, 1) % uint32(len(mp.pools)))
-	case LeastTasks:
-		leastTasks := 1<<31 - 1
-		for i, pool := range mp.pools {
-			if n := pool.Running(); n < leastTasks {
-				leastTasks = n
-				idx = i
-			}
+func (mp *MultiPool) newPool(spec PoolSpec) (*Pool, error) {
+	pool, err := NewPool(spec.Size, spec.Options...)
+	if err == nil && mp.spill != nil {
+		pool.onIdle = mp.spill.notify
+	}
+	return pool, err
+}
+
+func (mp *MultiPool) loadPools() *poolSet[*Pool] {
+	return mp.pools.Load().(*poolSet[*Pool])
+}
+
+func (mp *MultiPool) next(ps *poolSet[*Pool], lbs LoadBalancingStrategy) int {
+	if lbs == mp.lbs {
+		if idx := ps.balance(mp.balancer); idx >= 0 {
+			return idx
 		}
-		return
 	}
-	return -1
+	return ps.next(lbs, &mp.index, &mp.seed)
 }
 
 // Submit submits a task to a pool selected by the load-balancing strategy.
 func (mp *MultiPool) Submit(task func()) (err error) {
-	if mp.IsClosed() {
-		return ErrPoolClosed
-	}
-	if err = mp.pools[mp.next(mp.lbs)].Submit(task); err == nil {
+	f, err := mp.faults.inject()
+	if err != nil {
 		return
 	}
-	if err == ErrPoolOverload && mp.lbs == RoundRobin {
-		return mp.pools[mp.next(LeastTasks)].Submit(task)
+	if mp.spill != nil {
+		return mp.spill.submit(mp.IsClosed, func() error {
+			ps := mp.loadPools()
+			return trySpill(ps.pools, mp.next(ps, mp.lbs), func(p *Pool, task func()) error {
+				return p.trySubmit(task, f)
+			}, task)
+		})
+	}
+
+	for {
+		if mp.IsClosed() {
+			return ErrPoolClosed
+		}
+		ps := mp.loadPools()
+		if err = ps.pools[mp.next(ps, mp.lbs)].submit(task, f); err == nil {
+			return
+		}
+		if err == ErrPoolOverload && mp.lbs == RoundRobin {
+			err = ps.pools[mp.next(ps, LeastTasks)].submit(task, f)
+		}
+		// Retry with the latest pool set if the selected pool has been removed by Resize().
+		if err != ErrPoolClosed || mp.loadPools() == ps {
+			return
+		}
 	}
-	return
 }
 
 // Running returns the number of the currently running workers across all pools.
 func (mp *MultiPool) Running() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Running()
 	}
 	return
@@ -118,15 +581,16 @@ func (mp *MultiPool) Running() (n int) {
 
 // RunningByIndex returns the number of the currently running workers in the specific pool.
 func (mp *MultiPool) RunningByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Running(), nil
+	return pools[idx].Running(), nil
 }
 
 // Free returns the number of available workers across all pools.
 func (mp *MultiPool) Free() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Free()
 	}
 	return
@@ -134,44 +598,85 @@ func (mp *MultiPool) Free() (n int) {
 
 // FreeByIndex returns the number of available workers in the specific pool.
 func (mp *MultiPool) FreeByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Free(), nil
+	return pools[idx].Free(), nil
 }
 
 // Waiting returns the number of the currently waiting tasks across all pools.
 func (mp *MultiPool) Waiting() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Waiting()
 	}
+	if mp.spill != nil {
+		n += mp.spill.Waiting()
+	}
 	return
 }
 
 // WaitingByIndex returns the number of the currently waiting tasks in the specific pool.
 func (mp *MultiPool) WaitingByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Waiting(), nil
+	return pools[idx].Waiting(), nil
 }
 
 // Cap returns the capacity of this multi-pool.
 func (mp *MultiPool) Cap() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Cap()
 	}
 	return
 }
 
+// Size returns the number of pools in this multi-pool.
+func (mp *MultiPool) Size() int {
+	return len(mp.loadPools().pools)
+}
+
 // Tune resizes each pool in multi-pool.
 //
 // Note that this method doesn't resize the overall
 // capacity of multi-pool.
 func (mp *MultiPool) Tune(size int) {
-	for _, pool := range mp.pools {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	for _, pool := range mp.loadPools().pools {
 		pool.Tune(size)
 	}
+	if size > 0 {
+		mp.tuned = size
+	}
+}
+
+// Resize changes the number of pools in multi-pool.
+//
+// The new pools are instantiated in rotation with the specs that the multi-pool was
+// created with, the removed pools are released and drained gracefully: the tasks
+// running on them are allowed to complete, while the new tasks are routed to
+// the remaining pools.
+func (mp *MultiPool) Resize(size int) error {
+	if size <= 0 {
+		return ErrInvalidMultiPoolSize
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	if mp.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	ps, removed, err := mp.loadPools().resize(size, mp.specs, mp.tuned, mp.newPool)
+	if err != nil {
+		return err
+	}
+	mp.pools.Store(ps)
+	mp.draining.add(removed)
+	return nil
 }
 
 // IsClosed indicates whether the multi-pool is closed.
@@ -179,16 +684,24 @@ func (mp *MultiPool) IsClosed() bool {
 	return atomic.LoadInt32(&mp.state) == CLOSED
 }
 
-// ReleaseTimeout closes the multi-pool with a timeout,
-// it waits all pools to be closed before timing out.
+// ReleaseTimeout closes the multi-pool with a timeout, it waits all pools to be closed
+// before timing out, including the pools removed by Resize that are still draining.
 func (mp *MultiPool) ReleaseTimeout(timeout time.Duration) error {
+	mp.mu.Lock()
 	if !atomic.CompareAndSwapInt32(&mp.state, OPENED, CLOSED) {
+		mp.mu.Unlock()
 		return ErrPoolClosed
 	}
+	pools := mp.loadPools().pools
+	draining := mp.draining.list()
+	mp.mu.Unlock()
+	if mp.spill != nil {
+		mp.spill.close()
+	}
 
-	errCh := make(chan error, len(mp.pools))
+	errCh := make(chan error, len(pools)+len(draining))
 	var wg errgroup.Group
-	for i, pool := range mp.pools {
+	for i, pool := range pools {
 		func(p *Pool, idx int) {
 			wg.Go(func() error {
 				err := p.ReleaseTimeout(timeout)
@@ -200,11 +713,27 @@ func (mp *MultiPool) ReleaseTimeout(timeout time.Duration) error {
 			})
 		}(pool, i)
 	}
+	// The pools removed by Resize were released already, wait for the tasks still running on them.
+	for i, pool := range draining {
+		func(p *Pool, idx int) {
+			wg.Go(func() error {
+				err := p.waitReleased(timeout)
+				if err != nil {
+					err = fmt.Errorf("draining pool %d: %v", idx, err)
+				}
+				errCh <- err
+				return err
+			})
+		}(pool, i)
+	}
 
 	_ = wg.Wait()
+	mp.mu.Lock()
+	mp.draining.prune()
+	mp.mu.Unlock()
 
 	var errStr strings.Builder
-	for i := 0; i < len(mp.pools); i++ {
+	for i := 0; i < len(pools)+len(draining); i++ {
 		if err := <-errCh; err != nil {
 			errStr.WriteString(err.Error())
 			errStr.WriteString(" | ")
@@ -220,9 +749,11 @@ func (mp *MultiPool) ReleaseTimeout(timeout time.Duration) error {
 
 // Reboot reboots a released multi-pool.
 func (mp *MultiPool) Reboot() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
 	if atomic.CompareAndSwapInt32(&mp.state, CLOSED, OPENED) {
 		atomic.StoreUint32(&mp.index, 0)
-		for _, pool := range mp.pools {
+		for _, pool := range mp.loadPools().pools {
 			pool.Reboot()
 		}
 	}
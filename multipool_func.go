@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -39,10 +40,27 @@ import (
 // MultiPoolWithFunc is a good fit for the scenario where you have a large number of
 // tasks to submit, and you don't want the single pool to be the bottleneck.
 type MultiPoolWithFunc struct {
-	pools []*PoolWithFunc
-	index uint32
-	state int32
-	lbs   LoadBalancingStrategy
+	pools    atomic.Value // *poolSet[*PoolWithFunc]
+	index    uint32
+	seed     uint32
+	state    int32
+	lbs      LoadBalancingStrategy
+	balancer LoadBalancer
+
+	// mu serializes the operations that replace the pool set.
+	mu    sync.Mutex
+	specs []PoolSpec
+	tuned int
+	fn    func(any)
+
+	// draining are the pools removed by Resize, which might be still running tasks.
+	draining drainingPools[*PoolWithFunc]
+
+	// spill is not nil if the multi-pool is in spillover mode.
+	spill *spillover
+
+	// faults is not nil if the faults are injected into the multi-pool.
+	faults *multiPoolFaults
 }
 
 // NewMultiPoolWithFunc instantiates a MultiPoolWithFunc with a size of the pool list and a size
@@ -52,55 +70,106 @@ func NewMultiPoolWithFunc(size, sizePerPool int, fn func(any), lbs LoadBalancing
 		return nil, ErrInvalidMultiPoolSize
 	}
 
-	if lbs != RoundRobin && lbs != LeastTasks {
+	specs := make([]PoolSpec, size)
+	for i := range specs {
+		specs[i].Size = sizePerPool
+	}
+	return NewMultiPoolWithFuncSpecs(specs, fn, lbs, options...)
+}
+
+// NewMultiPoolWithFuncSpecs instantiates a MultiPoolWithFunc with a list of pool specs, each of which
+// specifies the size, weight and options of a pool, and the load-balancing strategy.
+// The given options are shared by all pools.
+func NewMultiPoolWithFuncSpecs(specs []PoolSpec, fn func(any), lbs LoadBalancingStrategy, options ...Option) (*MultiPoolWithFunc, error) {
+	if len(specs) == 0 {
+		return nil, ErrInvalidMultiPoolSize
+	}
+
+	if !lbs.isValid() {
 		return nil, ErrInvalidLoadBalancingStrategy
 	}
-	pools := make([]*PoolWithFunc, size)
-	for i := 0; i < size; i++ {
-		pool, err := NewPoolWithFunc(sizePerPool, fn, options...)
+	opts := loadOptions(options...)
+	specs = normalizeSpecs(specs, options)
+	mp := &MultiPoolWithFunc{
+		index:    math.MaxUint32,
+		lbs:      lbs,
+		balancer: opts.LoadBalancer,
+		specs:    specs,
+		fn:       fn,
+	}
+	if opts.Spillover {
+		mp.spill = newSpillover(opts)
+	}
+	mp.faults = newMultiPoolFaults(opts)
+	pools := make([]*PoolWithFunc, len(specs))
+	for i, spec := range specs {
+		pool, err := mp.newPool(spec)
 		if err != nil {
 			return nil, err
 		}
 		pools[i] = pool
 	}
-	return &MultiPoolWithFunc{pools: pools, index: math.MaxUint32, lbs: lbs}, nil
+	mp.pools.Store(newPoolSet(pools, specs))
+	return mp, nil
 }
 
-func (mp *MultiPoolWithFunc) next(lbs LoadBalancingStrategy) (idx int) {
-	switch lbs {
-	case RoundRobin:
-		return int(atomic.AddUint32(&mp.index, 1) % uint32(len(mp.pools)))
-	case LeastTasks:
-		leastTasks := 1<<31 - 1
-		for i, pool := range mp.pools {
-			if n := pool.Running(); n < leastTasks {
-				leastTasks = n
-				idx = i
-			}
+func (mp *MultiPoolWithFunc) newPool(spec PoolSpec) (*PoolWithFunc, error) {
+	pool, err := NewPoolWithFunc(spec.Size, mp.fn, spec.Options...)
+	if err == nil && mp.spill != nil {
+		pool.onIdle = mp.spill.notify
+	}
+	return pool, err
+}
+
+func (mp *MultiPoolWithFunc) loadPools() *poolSet[*PoolWithFunc] {
+	return mp.pools.Load().(*poolSet[*PoolWithFunc])
+}
+
+func (mp *MultiPoolWithFunc) next(ps *poolSet[*PoolWithFunc], lbs LoadBalancingStrategy) int {
+	if lbs == mp.lbs {
+		if idx := ps.balance(mp.balancer); idx >= 0 {
+			return idx
 		}
-		return
 	}
-	return -1
+	return ps.next(lbs, &mp.index, &mp.seed)
 }
 
 // Invoke submits a task to a pool selected by the load-balancing strategy.
 func (mp *MultiPoolWithFunc) Invoke(args any) (err error) {
-	if mp.IsClosed() {
-		return ErrPoolClosed
-	}
-
-	if err = mp.pools[mp.next(mp.lbs)].Invoke(args); err == nil {
+	f, err := mp.faults.inject()
+	if err != nil {
 		return
 	}
-	if err == ErrPoolOverload && mp.lbs == RoundRobin {
-		return mp.pools[mp.next(LeastTasks)].Invoke(args)
+	if mp.spill != nil {
+		return mp.spill.submit(mp.IsClosed, func() error {
+			ps := mp.loadPools()
+			return trySpill(ps.pools, mp.next(ps, mp.lbs), func(p *PoolWithFunc, args any) error {
+				return p.tryInvoke(args, f)
+			}, args)
+		})
+	}
+
+	for {
+		if mp.IsClosed() {
+			return ErrPoolClosed
+		}
+		ps := mp.loadPools()
+		if err = ps.pools[mp.next(ps, mp.lbs)].invoke(args, f); err == nil {
+			return
+		}
+		if err == ErrPoolOverload && mp.lbs == RoundRobin {
+			err = ps.pools[mp.next(ps, LeastTasks)].invoke(args, f)
+		}
+		// Retry with the latest pool set if the selected pool has been removed by Resize().
+		if err != ErrPoolClosed || mp.loadPools() == ps {
+			return
+		}
 	}
-	return
 }
 
 // Running returns the number of the currently running workers across all pools.
 func (mp *MultiPoolWithFunc) Running() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Running()
 	}
 	return
@@ -108,15 +177,16 @@ func (mp *MultiPoolWithFunc) Running() (n int) {
 
 // RunningByIndex returns the number of the currently running workers in the specific pool.
 func (mp *MultiPoolWithFunc) RunningByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Running(), nil
+	return pools[idx].Running(), nil
 }
 
 // Free returns the number of available workers across all pools.
 func (mp *MultiPoolWithFunc) Free() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Free()
 	}
 	return
@@ -124,44 +194,85 @@ func (mp *MultiPoolWithFunc) Free() (n int) {
 
 // FreeByIndex returns the number of available workers in the specific pool.
 func (mp *MultiPoolWithFunc) FreeByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Free(), nil
+	return pools[idx].Free(), nil
 }
 
 // Waiting returns the number of the currently waiting tasks across all pools.
 func (mp *MultiPoolWithFunc) Waiting() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Waiting()
 	}
+	if mp.spill != nil {
+		n += mp.spill.Waiting()
+	}
 	return
 }
 
 // WaitingByIndex returns the number of the currently waiting tasks in the specific pool.
 func (mp *MultiPoolWithFunc) WaitingByIndex(idx int) (int, error) {
-	if idx < 0 || idx >= len(mp.pools) {
+	pools := mp.loadPools().pools
+	if idx < 0 || idx >= len(pools) {
 		return -1, ErrInvalidPoolIndex
 	}
-	return mp.pools[idx].Waiting(), nil
+	return pools[idx].Waiting(), nil
 }
 
 // Cap returns the capacity of this multi-pool.
 func (mp *MultiPoolWithFunc) Cap() (n int) {
-	for _, pool := range mp.pools {
+	for _, pool := range mp.loadPools().pools {
 		n += pool.Cap()
 	}
 	return
 }
 
+// Size returns the number of pools in this multi-pool.
+func (mp *MultiPoolWithFunc) Size() int {
+	return len(mp.loadPools().pools)
+}
+
 // Tune resizes each pool in multi-pool.
 //
 // Note that this method doesn't resize the overall
 // capacity of multi-pool.
 func (mp *MultiPoolWithFunc) Tune(size int) {
-	for _, pool := range mp.pools {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	for _, pool := range mp.loadPools().pools {
 		pool.Tune(size)
 	}
+	if size > 0 {
+		mp.tuned = size
+	}
+}
+
+// Resize changes the number of pools in multi-pool.
+//
+// The new pools are instantiated in rotation with the specs that the multi-pool was
+// created with, the removed pools are released and drained gracefully: the tasks
+// running on them are allowed to complete, while the new tasks are routed to
+// the remaining pools.
+func (mp *MultiPoolWithFunc) Resize(size int) error {
+	if size <= 0 {
+		return ErrInvalidMultiPoolSize
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	if mp.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	ps, removed, err := mp.loadPools().resize(size, mp.specs, mp.tuned, mp.newPool)
+	if err != nil {
+		return err
+	}
+	mp.pools.Store(ps)
+	mp.draining.add(removed)
+	return nil
 }
 
 // IsClosed indicates whether the multi-pool is closed.
@@ -169,16 +280,24 @@ func (mp *MultiPoolWithFunc) IsClosed() bool {
 	return atomic.LoadInt32(&mp.state) == CLOSED
 }
 
-// ReleaseTimeout closes the multi-pool with a timeout,
-// it waits all pools to be closed before timing out.
+// ReleaseTimeout closes the multi-pool with a timeout, it waits all pools to be closed
+// before timing out, including the pools removed by Resize that are still draining.
 func (mp *MultiPoolWithFunc) ReleaseTimeout(timeout time.Duration) error {
+	mp.mu.Lock()
 	if !atomic.CompareAndSwapInt32(&mp.state, OPENED, CLOSED) {
+		mp.mu.Unlock()
 		return ErrPoolClosed
 	}
+	pools := mp.loadPools().pools
+	draining := mp.draining.list()
+	mp.mu.Unlock()
+	if mp.spill != nil {
+		mp.spill.close()
+	}
 
-	errCh := make(chan error, len(mp.pools))
+	errCh := make(chan error, len(pools)+len(draining))
 	var wg errgroup.Group
-	for i, pool := range mp.pools {
+	for i, pool := range pools {
 		func(p *PoolWithFunc, idx int) {
 			wg.Go(func() error {
 				err := p.ReleaseTimeout(timeout)
@@ -190,11 +309,27 @@ func (mp *MultiPoolWithFunc) ReleaseTimeout(timeout time.Duration) error {
 			})
 		}(pool, i)
 	}
+	// The pools removed by Resize were released already, wait for the tasks still running on them.
+	for i, pool := range draining {
+		func(p *PoolWithFunc, idx int) {
+			wg.Go(func() error {
+				err := p.waitReleased(timeout)
+				if err != nil {
+					err = fmt.Errorf("draining pool %d: %v", idx, err)
+				}
+				errCh <- err
+				return err
+			})
+		}(pool, i)
+	}
 
 	_ = wg.Wait()
+	mp.mu.Lock()
+	mp.draining.prune()
+	mp.mu.Unlock()
 
 	var errStr strings.Builder
-	for i := 0; i < len(mp.pools); i++ {
+	for i := 0; i < len(pools)+len(draining); i++ {
 		if err := <-errCh; err != nil {
 			errStr.WriteString(err.Error())
 			errStr.WriteString(" | ")
@@ -210,9 +345,11 @@ func (mp *MultiPoolWithFunc) ReleaseTimeout(timeout time.Duration) error {
 
 // Reboot reboots a released multi-pool.
 func (mp *MultiPoolWithFunc) Reboot() {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
 	if atomic.CompareAndSwapInt32(&mp.state, CLOSED, OPENED) {
 		atomic.StoreUint32(&mp.index, 0)
-		for _, pool := range mp.pools {
+		for _, pool := range mp.loadPools().pools {
 			pool.Reboot()
 		}
 	}
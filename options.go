@@ -22,7 +22,11 @@
 
 package ants
 
-import "time"
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
 
 // Option represents the optional function.
 type Option func(opts *Options)
@@ -37,6 +41,9 @@ func loadOptions(options ...Option) *Options {
 
 // Options contains all options which will be applied when instantiating an ants pool.
 type Options struct {
+	// Name is the name of the pool, it's used to tell pools apart in logs and panics.
+	Name string
+
 	// ExpiryDuration is a period for the scavenger goroutine to clean up those expired workers,
 	// the scavenger scans all workers every `ExpiryDuration` and clean up those workers that haven't been
 	// used for more than `ExpiryDuration`.
@@ -45,6 +52,21 @@ type Options struct {
 	// PreAlloc indicates whether to make memory pre-allocation when initializing Pool.
 	PreAlloc bool
 
+	// LockFreeQueue indicates whether to keep the idle workers in a lock-free queue, see WithLockFreeQueue.
+	LockFreeQueue bool
+
+	// ShardedWorkers indicates whether to keep the idle workers in shards, see WithShardedWorkers.
+	// It can't be set up along with LockFreeQueue, PreAlloc or WorkerQueue.
+	ShardedWorkers bool
+
+	// Locker returns the lock of the pool guarding the worker queue and the admission of tasks,
+	// the spin-lock of pkg/sync is used if it's nil, see WithLocker.
+	Locker func() sync.Locker
+
+	// WorkerQueue is the kind of queue keeping the idle workers, see WithWorkerQueue.
+	// It can't be set up along with ShardedWorkers or LockFreeQueue.
+	WorkerQueue WorkerQueueKind
+
 	// Max number of goroutine blocking on pool.Submit.
 	// 0 (default value) means no such limit.
 	MaxBlockingTasks int
@@ -60,12 +82,76 @@ type Options struct {
 	// stack trace of the goroutine
 	PanicHandler func(any)
 
+	// PanicHandlerV2 is like PanicHandler but receives the stack trace and the task along with
+	// the value given to panic, it takes precedence over PanicHandler.
+	PanicHandlerV2 func(PanicInfo)
+
+	// RateLimit and RateBurst set up the rate limit of task admission, see WithRateLimit.
+	RateLimit float64
+	RateBurst int
+
+	// PanicThreshold, PanicWindow and PanicCooldown set up the panic circuit breaker,
+	// see WithPanicCircuitBreaker.
+	PanicThreshold int
+	PanicWindow    time.Duration
+	PanicCooldown  time.Duration
+
+	// Tenants are the quotas of the tenants sharing the pool, keyed by the names of the tenants,
+	// see WithTenantQuota.
+	Tenants map[string]TenantQuota
+
+	// ErrorHandler is used to handle the errors returned by tasks, it's called
+	// on the worker goroutine right after the task returns a non-nil error.
+	ErrorHandler func(err error, info TaskInfo)
+
+	// TaskOverrunHandler is called when a task submitted by Pool.SubmitWithTimeout is still
+	// running after its timeout, with the stack trace of the goroutine running the task,
+	// which is nil if the stack traces were captured less than a second ago.
+	TaskOverrunHandler func(info TaskInfo, timeout time.Duration, stack []byte)
+
+	// SlowTaskThreshold and SlowTaskHandler set up the watchdog of slow or stuck tasks,
+	// SlowTaskHandler is called with the stack trace of the goroutine running the task
+	// once a task has been running for longer than SlowTaskThreshold.
+	SlowTaskThreshold time.Duration
+	SlowTaskHandler   func(info TaskInfo, elapsed time.Duration, stack []byte)
+
+	// When TaskTracking is true, the running tasks are tracked from the start instead of
+	// the first time the pool is inspected, see Pool.InFlight().
+	TaskTracking bool
+
 	// Logger is the customized logger for logging info, if it is not set,
 	// default standard logger from log package is used.
+	// Only the events at the error level are written to the Logger, the worker panics
+	// are written in the plain format "worker exits from panic: <value>\n<stack>".
 	Logger Logger
 
+	// Slogger is the structured logger for logging the events of the pool,
+	// it takes precedence over Logger.
+	Slogger *slog.Logger
+
 	// When DisablePurge is true, workers are not purged and are resident.
 	DisablePurge bool
+
+	// When SharedRuntime is true, the pool is served by the clock and scavenger goroutines
+	// shared by all such pools, instead of starting its own ones.
+	SharedRuntime bool
+
+	// FaultInjection describes the faults injected into the pool for testing, see WithFaultInjection.
+	FaultInjection *FaultInjection
+
+	// Clock is the source of time of the pool, the wall time is used if it's nil.
+	// SharedRuntime is inoperative with a Clock.
+	Clock Clock
+
+	// LoadBalancer is the custom load-balancing algorithm for multi-pools,
+	// it takes precedence over the LoadBalancingStrategy of a multi-pool.
+	LoadBalancer LoadBalancer
+
+	// When Spillover is true, a task submitted to a multi-pool is spilled over to
+	// the other pools if the selected pool is full, and a submitter blocked on
+	// the multi-pool is served by whichever pool frees up a worker first.
+	// Nonblocking and MaxBlockingTasks then apply to the multi-pool as a whole.
+	Spillover bool
 }
 
 // WithOptions accepts the whole Options config.
@@ -75,6 +161,13 @@ func WithOptions(options Options) Option {
 	}
 }
 
+// WithName sets up the name of the pool.
+func WithName(name string) Option {
+	return func(opts *Options) {
+		opts.Name = name
+	}
+}
+
 // WithExpiryDuration sets up the interval time of cleaning up goroutines.
 func WithExpiryDuration(expiryDuration time.Duration) Option {
 	return func(opts *Options) {
@@ -89,6 +182,59 @@ func WithPreAlloc(preAlloc bool) Option {
 	}
 }
 
+// WithLockFreeQueue indicates whether to keep the idle workers in a bounded lock-free queue,
+// through which the tasks are handed over to the idle workers without taking the lock of the pool,
+// so that a single pool scales with the number of submitters as well as a multi-pool does.
+//
+// Only the tasks that weigh 1 and belong to no tenant take the lock-free path, and only when no
+// tenant quotas are set up and the pool is not a child pool, the others are admitted as usual.
+// Like PreAlloc, it requires a limited capacity, which can't be tuned afterwards. The queue reuses
+// the idle workers in FIFO order, so NewPool returns ErrInvalidWorkerQueue along with WithWorkerQueue.
+func WithLockFreeQueue(lockFree bool) Option {
+	return func(opts *Options) {
+		opts.LockFreeQueue = lockFree
+	}
+}
+
+// WithShardedWorkers indicates whether to keep the idle workers in as many shards as GOMAXPROCS,
+// each of which has its own lock, and the workers are stolen from the other shards when one runs out.
+// It cuts down the contention on the pool like a multi-pool does, but the capacity, the blocked callers
+// and the statistics are still those of a single pool, and there is no load balancing to choose.
+//
+// The shards are striped locks rather than per-P caches: Go doesn't tell which P a goroutine runs on,
+// so a shard is picked by a lock-free random generator every time a worker is put back or taken,
+// and a worker doesn't stick to the P that put it back.
+//
+// The plain tasks are handed over to the idle workers without taking the lock of the pool,
+// on the same terms as WithLockFreeQueue, but neither a limited capacity is required nor is
+// the capacity fixed. The shards are neither preallocated nor ordered by anything but their own
+// LIFO stacks, so NewPool returns ErrInvalidWorkerQueue along with WithLockFreeQueue, WithPreAlloc
+// or WithWorkerQueue.
+func WithShardedWorkers(sharded bool) Option {
+	return func(opts *Options) {
+		opts.ShardedWorkers = sharded
+	}
+}
+
+// WithWorkerQueue sets up the kind of queue keeping the idle workers, which decides the order
+// the idle workers are reused in, see WorkerQueueKind. Under PreAlloc mode, the memory of
+// the queue is allocated for the capacity of the pool upfront, whichever kind it is.
+func WithWorkerQueue(kind WorkerQueueKind) Option {
+	return func(opts *Options) {
+		opts.WorkerQueue = kind
+	}
+}
+
+// WithLocker sets up the constructor of the lock of the pool, which is called once for every pool,
+// including each pool of a multi-pool. The default spin-lock suits the short critical sections
+// of the pool, while the other locks of pkg/sync, e.g. NewAdaptiveLock, behave better when
+// there are many more submitters than CPUs and the lock holders get preempted.
+func WithLocker(newLocker func() sync.Locker) Option {
+	return func(opts *Options) {
+		opts.Locker = newLocker
+	}
+}
+
 // WithMaxBlockingTasks sets up the maximum number of goroutines that are blocked when it reaches the capacity of pool.
 func WithMaxBlockingTasks(maxBlockingTasks int) Option {
 	return func(opts *Options) {
@@ -110,6 +256,85 @@ func WithPanicHandler(panicHandler func(any)) Option {
 	}
 }
 
+// WithPanicHandlerV2 sets up the panic handler that receives the context of panics.
+func WithPanicHandlerV2(panicHandler func(PanicInfo)) Option {
+	return func(opts *Options) {
+		opts.PanicHandlerV2 = panicHandler
+	}
+}
+
+// WithRateLimit sets up a token bucket that limits the rate of submitting tasks to rate tasks per second,
+// allowing bursts of up to burst tasks.
+//
+// A task exceeding the rate limit waits until it's admitted, unless the pool is in nonblocking mode or
+// the number of blocked callers reaches MaxBlockingTasks, in which case ErrRateLimited is returned at once.
+func WithRateLimit(rate float64, burst int) Option {
+	return func(opts *Options) {
+		opts.RateLimit = rate
+		opts.RateBurst = burst
+	}
+}
+
+// WithPanicCircuitBreaker sets up the circuit breaker that stops the pool from accepting tasks
+// after more than threshold tasks panic within the window.
+//
+// Once the breaker is open, submitting tasks fails fast with ErrCircuitOpen. After the cooldown,
+// the breaker lets one task through as a probe, the breaker is closed if the probe returns normally,
+// or opened again if any task panics. Another task is let through if the probe hangs for another cooldown.
+func WithPanicCircuitBreaker(threshold int, window, cooldown time.Duration) Option {
+	return func(opts *Options) {
+		opts.PanicThreshold = threshold
+		opts.PanicWindow = window
+		opts.PanicCooldown = cooldown
+	}
+}
+
+// WithTenantQuota sets up the quota of a tenant submitting tasks by Pool.SubmitForTenant.
+//
+// The capacity units reserved for all tenants are expected to be within the capacity of the pool,
+// otherwise the tenants without reservations might never be served.
+func WithTenantQuota(tenant string, quota TenantQuota) Option {
+	return func(opts *Options) {
+		if opts.Tenants == nil {
+			opts.Tenants = make(map[string]TenantQuota)
+		}
+		opts.Tenants[tenant] = quota
+	}
+}
+
+// WithErrorHandler sets up the handler of task errors.
+func WithErrorHandler(errorHandler func(err error, info TaskInfo)) Option {
+	return func(opts *Options) {
+		opts.ErrorHandler = errorHandler
+	}
+}
+
+// WithTaskOverrunHandler sets up the handler of tasks overrunning their timeouts.
+func WithTaskOverrunHandler(overrunHandler func(info TaskInfo, timeout time.Duration, stack []byte)) Option {
+	return func(opts *Options) {
+		opts.TaskOverrunHandler = overrunHandler
+	}
+}
+
+// WithSlowTaskWatchdog sets up the watchdog that reports the tasks running for longer than the threshold.
+//
+// The watchdog runs along with the goroutine that updates the current time of the pool, so the tasks
+// are checked every 500 milliseconds, and the handler is expected to return quickly.
+func WithSlowTaskWatchdog(threshold time.Duration, handler func(info TaskInfo, elapsed time.Duration, stack []byte)) Option {
+	return func(opts *Options) {
+		opts.SlowTaskThreshold = threshold
+		opts.SlowTaskHandler = handler
+	}
+}
+
+// WithTaskTracking indicates whether the running tasks should be tracked from the start,
+// so that Pool.InFlight() and Pool.IdleWorkers() report all of them.
+func WithTaskTracking(taskTracking bool) Option {
+	return func(opts *Options) {
+		opts.TaskTracking = taskTracking
+	}
+}
+
 // WithLogger sets up a customized logger.
 func WithLogger(logger Logger) Option {
 	return func(opts *Options) {
@@ -117,9 +342,63 @@ func WithLogger(logger Logger) Option {
 	}
 }
 
+// WithSlogger sets up a structured logger.
+func WithSlogger(logger *slog.Logger) Option {
+	return func(opts *Options) {
+		opts.Slogger = logger
+	}
+}
+
 // WithDisablePurge indicates whether we turn off automatically purge.
 func WithDisablePurge(disable bool) Option {
 	return func(opts *Options) {
 		opts.DisablePurge = disable
 	}
 }
+
+// WithSharedRuntime indicates whether the pool shares the clock and scavenger goroutines with the other pools.
+//
+// Every pool starts two goroutines by default, one updates the current time of the pool and the other
+// purges its stale workers, each with its own ticker. The pools with the shared runtime are served by
+// one clock goroutine and one scavenger goroutine for the whole process instead, while the ExpiryDuration
+// of each pool is still respected, which saves memory and wakeups for the processes with many pools.
+func WithSharedRuntime(shared bool) Option {
+	return func(opts *Options) {
+		opts.SharedRuntime = shared
+	}
+}
+
+// WithClock sets up the source of time of the pool, which makes the expiry of workers, the slow task
+// watchdog, and the delayed tasks, e.g. retries and timeouts, deterministic in tests,
+// see antstest.FakeClock.
+func WithClock(clock Clock) Option {
+	return func(opts *Options) {
+		opts.Clock = clock
+	}
+}
+
+// WithFaultInjection sets up the faults injected into the pool, which helps to test the handling of
+// overloads, delays and panics: a fraction of submissions are rejected with ErrPoolOverload, the others
+// are delayed before retrieving workers, and their tasks are delayed or replaced by panics on the workers.
+//
+// All kinds of pools, including multi-pools, inject the faults in the same way, and the faults are
+// reproducible as they're drawn from a seeded sequence, see FaultInjection.
+func WithFaultInjection(fi *FaultInjection) Option {
+	return func(opts *Options) {
+		opts.FaultInjection = fi
+	}
+}
+
+// WithLoadBalancer sets up a custom load balancer for multi-pools.
+func WithLoadBalancer(balancer LoadBalancer) Option {
+	return func(opts *Options) {
+		opts.LoadBalancer = balancer
+	}
+}
+
+// WithSpillover indicates whether multi-pools spill tasks over between pools.
+func WithSpillover(spillover bool) Option {
+	return func(opts *Options) {
+		opts.Spillover = spillover
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+func TestManualPool(t *testing.T) {
+	p := NewManualPool(2)
+	var order []int
+	for i := 0; i < 2; i++ {
+		i := i
+		require.NoError(t, p.Submit(func() { order = append(order, i) }))
+	}
+	require.EqualValues(t, 2, p.Running())
+	require.EqualValues(t, 0, p.Free())
+
+	// The submitters blocked on the capacity are admitted in order as the tasks return.
+	errs := make(chan error, 2)
+	for i := 2; i < 4; i++ {
+		i := i
+		go func() {
+			errs <- p.Submit(func() { order = append(order, i) })
+		}()
+		require.Eventually(t, func() bool { return p.Waiting() == i-1 }, time.Second, time.Millisecond)
+	}
+	require.True(t, p.RunNext())
+	require.NoError(t, <-errs)
+	require.EqualValues(t, 1, p.Waiting())
+	require.EqualValues(t, 3, p.RunAll())
+	require.NoError(t, <-errs)
+	require.False(t, p.RunNext())
+	require.EqualValues(t, []int{0, 1, 2, 3}, order)
+
+	// Nonblocking mode and MaxBlockingTasks are respected.
+	p = NewManualPool(1, ants.WithNonblocking(true))
+	require.NoError(t, p.Submit(func() {}))
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolOverload)
+	p = NewManualPool(1, ants.WithMaxBlockingTasks(1))
+	require.NoError(t, p.Submit(func() {}))
+	go func() {
+		errs <- p.Submit(func() {})
+	}()
+	require.Eventually(t, func() bool { return p.Waiting() == 1 }, time.Second, time.Millisecond)
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolOverload)
+
+	// The blocked submitters get ErrPoolClosed once the pool is released.
+	p.Release()
+	require.ErrorIs(t, <-errs, ants.ErrPoolClosed)
+	require.ErrorIs(t, p.Submit(func() {}), ants.ErrPoolClosed)
+	require.False(t, p.RunNext())
+
+	// The panics are passed to the panic handler.
+	var pr PanicRecorder
+	p = NewManualPool(0, ants.WithName("manual"), pr.Option())
+	require.NoError(t, p.Submit(func() { panic("oops") }))
+	require.True(t, p.RunNext())
+	require.Len(t, pr.Panics(), 1)
+	require.Equal(t, "oops", pr.Panics()[0].Value)
+	require.Equal(t, "manual", pr.Panics()[0].Pool)
+}
+
+func TestAssertNoLeakedWorkers(t *testing.T) {
+	var pr PanicRecorder
+	p, err := ants.NewPool(10, pr.Option(), ants.WithTaskTracking(true))
+	require.NoError(t, err)
+	ch := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Submit(func() { <-ch }))
+	}
+	require.NoError(t, p.Submit(func() { panic("oops") }))
+	require.Eventually(t, func() bool { return len(pr.Panics()) == 1 }, time.Second, time.Millisecond)
+	close(ch)
+	RequireDrained(t, p)
+	// The goroutines of the workers are known to the pool tracking the tasks from the start.
+	require.NotEmpty(t, p.IdleWorkers())
+	for _, w := range p.IdleWorkers() {
+		require.NotZero(t, w.GoroutineID)
+	}
+	require.True(t, AssertNoLeakedWorkers(t, p))
+	require.Zero(t, p.Running())
+
+	// The workers whose goroutines are unknown to the pool are reported.
+	p, err = ants.NewPool(10)
+	require.NoError(t, err)
+	require.NoError(t, p.Submit(func() {}))
+	RequireDrained(t, p)
+	var mt mockT
+	require.False(t, AssertNoLeakedWorkers(&mt, p))
+	require.Len(t, mt.errors, 1)
+	require.Contains(t, mt.errors[0], "the goroutines of 1 idle workers are unknown")
+	require.Zero(t, p.Running())
+
+	// A worker stuck in a task is reported.
+	p, err = ants.NewPool(10)
+	require.NoError(t, err)
+	block := make(chan struct{})
+	defer close(block)
+	require.NoError(t, p.Submit(func() { <-block }))
+	mt = mockT{}
+	require.False(t, AssertNoLeakedWorkers(&mt, p))
+	require.Len(t, mt.errors, 1)
+
+	// A worker goroutine that outlives the pool is reported even if the pool is released.
+	p, err = ants.NewPool(10)
+	require.NoError(t, err)
+	gid := make(chan uint64)
+	go func() {
+		gid <- currentGoroutineID()
+		<-block
+	}()
+	lp := &leakyPool{Pool: p, gid: <-gid}
+	mt = mockT{}
+	require.False(t, AssertNoLeakedWorkers(&mt, lp))
+	require.Len(t, mt.errors, 1)
+	require.Contains(t, mt.errors[0], fmt.Sprintf("goroutines [%d] are still alive", lp.gid))
+	require.Zero(t, p.Running())
+}
+
+// leakyPool is a pool that reports a goroutine which never exits as one of its idle workers.
+type leakyPool struct {
+	*ants.Pool
+	gid uint64
+}
+
+func (p *leakyPool) IdleWorkers() []ants.WorkerInfo {
+	return append(p.Pool.IdleWorkers(), ants.WorkerInfo{GoroutineID: p.gid})
+}
+
+func currentGoroutineID() uint64 {
+	gids := strings.Fields(strings.TrimPrefix(string(debug.Stack()), "goroutine "))
+	id, _ := strconv.ParseUint(gids[0], 10, 64)
+	return id
+}
+
+func TestLogRecorder(t *testing.T) {
+	var lr LogRecorder
+	p, err := ants.NewPool(1, ants.WithName("recorded"), ants.WithSlogger(lr.Slogger(slog.LevelInfo)))
+	require.NoError(t, err)
+	p.Release()
+	msgs := lr.Messages()
+	require.Len(t, msgs, 1)
+	require.True(t, strings.HasPrefix(msgs[0], "pool released pool=recorded"), msgs[0])
+}
+
+// mockT records the errors reported by the helpers.
+type mockT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *mockT) Helper() {}
+
+func (t *mockT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// FakeClock is an ants.Clock that only moves when it's advanced by hand, which makes the expiry
+// of workers, the slow task watchdog, and the delayed tasks of a pool deterministic in tests.
+//
+//	clock := antstest.NewFakeClock(time.Now())
+//	p, _ := ants.NewPool(10, ants.WithClock(clock), ants.WithExpiryDuration(time.Minute))
+//	...
+//	clock.Advance(time.Minute) // purges the workers idle for a minute at once
+//
+// Note that the ticks of the tickers are delivered to the goroutines of the pool asynchronously,
+// as they would be by a real ticker.
+type FakeClock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock starting at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// fakeTimer is a timer or a ticker of a FakeClock.
+type fakeTimer struct {
+	clock  *FakeClock
+	when   time.Time
+	period time.Duration  // the period of a ticker, 0 for a timer
+	ch     chan time.Time // the channel of a ticker
+	f      func()         // the function of a timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.clock.remove(t)
+}
+
+// fakeTicker is the ants.Ticker of a fakeTimer with a period.
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Chan() <-chan time.Time {
+	return t.ch
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker that ticks every time the clock is advanced past its period.
+func (c *FakeClock) NewTicker(d time.Duration) ants.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, when: c.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	c.add(t)
+	return fakeTicker{t}
+}
+
+// AfterFunc calls f in its own goroutine once the clock is advanced by d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) ants.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, when: c.now.Add(d), f: f}
+	if d <= 0 {
+		go f()
+		return t
+	}
+	c.add(t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers and the tickers due in order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.when.After(end) && (next == nil || t.when.Before(next.when)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		c.now = next.when
+		if next.period > 0 {
+			// Drop the tick if the last one hasn't been received, like time.Ticker.
+			select {
+			case next.ch <- c.now:
+			default:
+			}
+			next.when = next.when.Add(next.period)
+		} else {
+			c.removeLocked(next)
+			go next.f()
+		}
+	}
+	c.now = end
+}
+
+// BlockUntil blocks until there are at least n active timers and tickers, it's useful to make sure
+// the goroutines of a pool have set up their tickers before advancing the clock.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) add(t *fakeTimer) {
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.removeLocked(t)
+}
+
+func (c *FakeClock) removeLocked(t *fakeTimer) bool {
+	for i, tt := range c.timers {
+		if tt == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			c.cond.Broadcast()
+			return true
+		}
+	}
+	return false
+}
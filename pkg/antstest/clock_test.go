@@ -0,0 +1,41 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFakeClock(start)
+	require.Equal(t, start, c.Now())
+
+	fired := make(chan time.Time, 3)
+	c.AfterFunc(2*time.Second, func() { fired <- c.Now() })
+	stopped := c.AfterFunc(time.Second, func() { fired <- time.Time{} })
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+	ticker := c.NewTicker(time.Second)
+	c.BlockUntil(2)
+
+	c.Advance(time.Second)
+	require.Equal(t, start.Add(time.Second), <-ticker.Chan())
+	require.Empty(t, fired)
+
+	// The ticks not received are dropped like time.Ticker.
+	c.Advance(3 * time.Second)
+	require.Equal(t, start.Add(4*time.Second), <-fired)
+	require.Equal(t, start.Add(2*time.Second), <-ticker.Chan())
+	require.Empty(t, ticker.Chan())
+	require.Equal(t, start.Add(4*time.Second), c.Now())
+
+	ticker.Stop()
+	c.Advance(time.Minute)
+	require.Empty(t, ticker.Chan())
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// DefaultTimeout is how long the helpers wait for a pool to reach the expected state.
+const DefaultTimeout = 5 * time.Second
+
+// Pool is the part of the ants pools inspected by the helpers, which is implemented by
+// ants.Pool, ants.PoolWithFunc and ants.PoolWithFuncGeneric.
+type Pool interface {
+	Running() int
+	Waiting() int
+	InFlight() []ants.TaskInfo
+	IdleWorkers() []ants.WorkerInfo
+	ReleaseTimeout(timeout time.Duration) error
+}
+
+// AssertNoLeakedWorkers releases the pool with ReleaseTimeout and asserts that all its workers exit,
+// i.e. Running() reaches 0 and the worker goroutines known to the pool are gone, within DefaultTimeout.
+//
+// The worker goroutines are told apart by the IDs reported by InFlight() and IdleWorkers() right before
+// the pool is released, so the pool is expected to be created with ants.WithTaskTracking(true),
+// an idle worker whose goroutine is unknown to the pool fails the assertion.
+func AssertNoLeakedWorkers(t testing.TB, pool Pool) bool {
+	t.Helper()
+
+	var (
+		gids    []uint64
+		unknown int
+	)
+	for _, task := range pool.InFlight() {
+		gids = append(gids, task.GoroutineID)
+	}
+	for _, w := range pool.IdleWorkers() {
+		if w.GoroutineID == 0 {
+			unknown++
+			continue
+		}
+		gids = append(gids, w.GoroutineID)
+	}
+	if unknown > 0 {
+		t.Errorf("the goroutines of %d idle workers are unknown, create the pool with ants.WithTaskTracking(true)", unknown)
+		_ = pool.ReleaseTimeout(DefaultTimeout)
+		return false
+	}
+
+	if err := pool.ReleaseTimeout(DefaultTimeout); err != nil {
+		t.Errorf("failed to release the pool: %v, %d workers are still running", err, pool.Running())
+		return false
+	}
+
+	var alive []uint64
+	ok := poll(func() bool {
+		alive = aliveGoroutines(gids)
+		return pool.Running() == 0 && len(alive) == 0
+	})
+	if !ok {
+		t.Errorf("leaked workers: %d workers are still running, goroutines %v are still alive", pool.Running(), alive)
+	}
+	return ok
+}
+
+// RequireDrained fails the test at once unless the pool has no running task and no blocked submitter
+// within DefaultTimeout, i.e. all the workers are idle.
+func RequireDrained(t testing.TB, pool Pool) {
+	t.Helper()
+
+	var running, waiting int
+	ok := poll(func() bool {
+		running, waiting = pool.Running()-len(pool.IdleWorkers()), pool.Waiting()
+		return running <= 0 && waiting == 0
+	})
+	if !ok {
+		t.Fatalf("the pool is not drained: %d tasks are running, %d tasks are waiting", running, waiting)
+	}
+}
+
+// poll reports whether the condition is satisfied within DefaultTimeout.
+func poll(cond func() bool) bool {
+	for deadline := time.Now().Add(DefaultTimeout); ; {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// aliveGoroutines returns the goroutines in gids that are still alive.
+func aliveGoroutines(gids []uint64) (alive []uint64) {
+	if len(gids) == 0 {
+		return nil
+	}
+
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	existing := make(map[uint64]struct{})
+	for _, line := range bytes.Split(buf, []byte("\n")) {
+		// The header of each goroutine is like "goroutine 18 [running]:".
+		if rest, ok := bytes.CutPrefix(line, []byte("goroutine ")); ok {
+			if i := bytes.IndexByte(rest, ' '); i > 0 {
+				if id, err := strconv.ParseUint(string(rest[:i]), 10, 64); err == nil {
+					existing[id] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, id := range gids {
+		if _, ok := existing[id]; ok {
+			alive = append(alive, id)
+		}
+	}
+	return
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"sync"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// ManualPool is a deterministic stand-in for ants.Pool, its tasks never run until the test tells it to.
+//
+// It admits the submitted tasks like an ants.Pool with the same capacity and options: a task is admitted
+// if the admitted tasks don't run out of the capacity, otherwise the submitter is blocked, or rejected
+// with ants.ErrPoolOverload in nonblocking mode or when MaxBlockingTasks is reached. The admitted tasks
+// are run one by one on the goroutine of the test by RunNext or RunAll in the order of admission,
+// and the blocked submitters are admitted in the order they arrived as soon as a task returns.
+// The panics of the tasks are passed to the PanicHandler or PanicHandlerV2 of the options, if any.
+type ManualPool struct {
+	capacity int
+	opts     ants.Options
+
+	mu      sync.Mutex
+	closed  bool
+	running int // the number of the tasks being run by RunNext
+	pending []func()
+	waiters []*manualWaiter
+}
+
+// manualWaiter is a submitter blocked on the capacity of a ManualPool.
+type manualWaiter struct {
+	task func()
+	done chan error
+}
+
+// NewManualPool returns a ManualPool with the capacity and the options of ants.NewPool,
+// a non-positive size means the pool is unlimited.
+func NewManualPool(size int, options ...ants.Option) *ManualPool {
+	if size <= 0 {
+		size = -1
+	}
+	p := &ManualPool{capacity: size}
+	for _, opt := range options {
+		opt(&p.opts)
+	}
+	return p
+}
+
+// Submit submits a task to the pool.
+func (p *ManualPool) Submit(task func()) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ants.ErrPoolClosed
+	}
+	if p.capacity == -1 || p.running+len(p.pending) < p.capacity {
+		p.pending = append(p.pending, task)
+		p.mu.Unlock()
+		return nil
+	}
+	if p.opts.Nonblocking || (p.opts.MaxBlockingTasks != 0 && len(p.waiters) >= p.opts.MaxBlockingTasks) {
+		p.mu.Unlock()
+		return ants.ErrPoolOverload
+	}
+	w := &manualWaiter{task: task, done: make(chan error, 1)}
+	p.waiters = append(p.waiters, w)
+	p.mu.Unlock()
+	return <-w.done
+}
+
+// RunNext runs the oldest admitted task on the calling goroutine, it returns false if there is none.
+func (p *ManualPool) RunNext() bool {
+	p.mu.Lock()
+	if len(p.pending) == 0 {
+		p.mu.Unlock()
+		return false
+	}
+	task := p.pending[0]
+	p.pending[0] = nil
+	p.pending = p.pending[1:]
+	p.running++
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.running--
+		p.admit()
+		p.mu.Unlock()
+	}()
+	p.run(task)
+	return true
+}
+
+// admit admits the submitter blocked for the longest time, if any, it must be called with p.mu held.
+func (p *ManualPool) admit() {
+	if len(p.waiters) > 0 && !p.closed {
+		w := p.waiters[0]
+		p.waiters[0] = nil
+		p.waiters = p.waiters[1:]
+		p.pending = append(p.pending, w.task)
+		w.done <- nil
+	}
+}
+
+// RunAll runs the admitted tasks until there is none left, including the ones admitted meanwhile,
+// and returns the number of the tasks it has run.
+func (p *ManualPool) RunAll() (n int) {
+	for p.RunNext() {
+		n++
+	}
+	return
+}
+
+func (p *ManualPool) run(task func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch {
+			case p.opts.PanicHandlerV2 != nil:
+				p.opts.PanicHandlerV2(ants.PanicInfo{Value: r, Pool: p.opts.Name})
+			case p.opts.PanicHandler != nil:
+				p.opts.PanicHandler(r)
+			default:
+				panic(r)
+			}
+		}
+	}()
+	task()
+}
+
+// Running returns the number of the admitted tasks that have not returned yet.
+func (p *ManualPool) Running() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running + len(p.pending)
+}
+
+// Pending returns the number of the admitted tasks that have not run yet.
+func (p *ManualPool) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// Waiting returns the number of the submitters blocked on the pool.
+func (p *ManualPool) Waiting() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.waiters)
+}
+
+// Cap returns the capacity of the pool, -1 indicates the pool is unlimited.
+func (p *ManualPool) Cap() int {
+	return p.capacity
+}
+
+// Free returns the number of the tasks the pool can admit at once, -1 indicates the pool is unlimited.
+func (p *ManualPool) Free() int {
+	if p.capacity == -1 {
+		return -1
+	}
+	return p.capacity - p.Running()
+}
+
+// IsClosed indicates whether the pool is closed.
+func (p *ManualPool) IsClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// Release closes the pool, the admitted tasks that have not run are dropped,
+// and the blocked submitters get ants.ErrPoolClosed.
+func (p *ManualPool) Release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for _, w := range p.waiters {
+		w.done <- ants.ErrPoolClosed
+	}
+	p.waiters = nil
+	p.pending = nil
+}
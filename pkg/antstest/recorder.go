@@ -0,0 +1,66 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package antstest
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/panjf2000/ants/v2"
+)
+
+// PanicRecorder records the panics of the tasks of a pool, it's set up by ants.WithPanicHandlerV2
+// with its Handle method, or by its Option method.
+type PanicRecorder struct {
+	mu     sync.Mutex
+	panics []ants.PanicInfo
+}
+
+// Handle records a panic, it's the panic handler of ants.WithPanicHandlerV2.
+func (r *PanicRecorder) Handle(info ants.PanicInfo) {
+	r.mu.Lock()
+	r.panics = append(r.panics, info)
+	r.mu.Unlock()
+}
+
+// Option returns the option that sets up the recorder as the panic handler of a pool.
+func (r *PanicRecorder) Option() ants.Option {
+	return ants.WithPanicHandlerV2(r.Handle)
+}
+
+// Panics returns the panics recorded so far.
+func (r *PanicRecorder) Panics() []ants.PanicInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ants.PanicInfo(nil), r.panics...)
+}
+
+// LogRecorder is an ants.Logger that records the messages written to it.
+type LogRecorder struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// Printf records a formatted message.
+func (r *LogRecorder) Printf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	r.mu.Lock()
+	r.messages = append(r.messages, msg)
+	r.mu.Unlock()
+}
+
+// Slogger returns a structured logger that writes the records at or above the level to the recorder,
+// see ants.NewLoggerHandler for the format of the messages.
+func (r *LogRecorder) Slogger(level slog.Leveler) *slog.Logger {
+	return slog.New(ants.NewLoggerHandler(r, level))
+}
+
+// Messages returns the messages recorded so far.
+func (r *LogRecorder) Messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...)
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	unlocked int32 = iota
+	locked
+	contended // locked, and there might be callers parked
+)
+
+// maxSpins is the number of rounds an adaptiveLock spins before parking.
+const maxSpins = 4
+
+// adaptiveLock spins a few rounds with exponential backoff, which is enough to acquire a lock held
+// briefly, and then parks the caller until the lock is released, so that the callers don't burn CPU
+// when the holder is preempted or holds the lock for long.
+type adaptiveLock struct {
+	state atomic.Int32
+	wake  chan struct{}
+}
+
+func (al *adaptiveLock) Lock() {
+	if al.state.CompareAndSwap(unlocked, locked) {
+		return
+	}
+
+	backoff := 1
+	for i := 0; i < maxSpins; i++ {
+		for j := 0; j < backoff; j++ {
+			runtime.Gosched()
+		}
+		backoff <<= 1
+		if al.state.Load() == unlocked && al.state.CompareAndSwap(unlocked, locked) {
+			return
+		}
+	}
+
+	// Mark the lock as contended, so that the holder wakes up a parked caller when it releases the lock.
+	for al.state.Swap(contended) != unlocked {
+		<-al.wake
+	}
+}
+
+func (al *adaptiveLock) Unlock() {
+	if al.state.Swap(unlocked) == contended {
+		select {
+		case al.wake <- struct{}{}:
+		default: // there is a wakeup pending already
+		}
+	}
+}
+
+// NewAdaptiveLock instantiates a lock that spins briefly and then parks the caller,
+// which degrades gracefully when the lock holder gets preempted.
+func NewAdaptiveLock() sync.Locker {
+	return &adaptiveLock{wake: make(chan struct{}, 1)}
+}
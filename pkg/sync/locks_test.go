@@ -0,0 +1,102 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+var locks = []struct {
+	name    string
+	newLock func() sync.Locker
+}{
+	{"Mutex", func() sync.Locker { return new(sync.Mutex) }},
+	{"BackOffSpinLock", NewSpinLock},
+	{"TicketLock", NewTicketLock},
+	{"MCSLock", NewMCSLock},
+	{"AdaptiveLock", NewAdaptiveLock},
+}
+
+func TestLocks(t *testing.T) {
+	for _, l := range locks {
+		l := l
+		t.Run(l.name, func(t *testing.T) {
+			var (
+				lock    = l.newLock()
+				wg      sync.WaitGroup
+				counter int
+			)
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 10000; j++ {
+						lock.Lock()
+						counter++
+						lock.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if counter != 80000 {
+				t.Fatalf("the lock doesn't exclude the others, expected 80000 but got %d", counter)
+			}
+		})
+	}
+}
+
+func BenchmarkTicketLock(b *testing.B) {
+	lock := NewTicketLock()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lock.Lock()
+			//nolint:staticcheck
+			lock.Unlock()
+		}
+	})
+}
+
+func BenchmarkMCSLock(b *testing.B) {
+	lock := NewMCSLock()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lock.Lock()
+			//nolint:staticcheck
+			lock.Unlock()
+		}
+	})
+}
+
+func BenchmarkAdaptiveLock(b *testing.B) {
+	lock := NewAdaptiveLock()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			lock.Lock()
+			//nolint:staticcheck
+			lock.Unlock()
+		}
+	})
+}
+
+// BenchmarkOversubscribedLocks runs many more goroutines than Ps with a critical section
+// that yields the P, which is how the locks behave when their holders get preempted.
+func BenchmarkOversubscribedLocks(b *testing.B) {
+	for _, l := range locks {
+		l := l
+		b.Run(l.name, func(b *testing.B) {
+			lock := l.newLock()
+			b.SetParallelism(16)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					lock.Lock()
+					runtime.Gosched()
+					lock.Unlock()
+				}
+			})
+		})
+	}
+}
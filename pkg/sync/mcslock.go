@@ -0,0 +1,65 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// mcsNode is the place of a caller in the queue of mcsLock.
+type mcsNode struct {
+	next   atomic.Pointer[mcsNode]
+	locked atomic.Bool
+}
+
+var mcsNodePool = sync.Pool{New: func() any { return new(mcsNode) }}
+
+// mcsLock is the queue lock of Mellor-Crummey and Scott: the callers line up in a linked list
+// and each of them spins on its own node until the predecessor hands the lock over, so they
+// don't contend on a shared word while waiting, and the lock is granted in the order of arrival.
+type mcsLock struct {
+	tail atomic.Pointer[mcsNode]
+	// owner is the node of the caller holding the lock, it's guarded by the lock itself.
+	owner *mcsNode
+}
+
+func (ml *mcsLock) Lock() {
+	node := mcsNodePool.Get().(*mcsNode)
+	node.next.Store(nil)
+	node.locked.Store(true)
+	if prev := ml.tail.Swap(node); prev != nil {
+		prev.next.Store(node)
+		for node.locked.Load() {
+			runtime.Gosched()
+		}
+	}
+	ml.owner = node
+}
+
+func (ml *mcsLock) Unlock() {
+	node := ml.owner
+	ml.owner = nil
+	next := node.next.Load()
+	if next == nil {
+		if ml.tail.CompareAndSwap(node, nil) {
+			mcsNodePool.Put(node)
+			return
+		}
+		// A successor has taken its place in the queue but not linked itself to this node yet.
+		for next = node.next.Load(); next == nil; next = node.next.Load() {
+			runtime.Gosched()
+		}
+	}
+	next.locked.Store(false)
+	mcsNodePool.Put(node)
+}
+
+// NewMCSLock instantiates an MCS queue lock, which is fair to the callers and
+// keeps the cache traffic low under heavy contention.
+func NewMCSLock() sync.Locker {
+	return new(mcsLock)
+}
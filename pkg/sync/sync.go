@@ -20,6 +20,7 @@
  * SOFTWARE.
  */
 
-// Package sync provides some handy implementations for synchronization access.
-// At the moment, there is only an implementation of spin-lock.
+// Package sync provides some handy implementations for synchronization access:
+// a spin-lock with exponential backoff, a fair ticket lock, an MCS queue lock
+// and an adaptive lock that spins briefly and then parks.
 package sync
@@ -0,0 +1,43 @@
+// Copyright 2025 Andy Pan. All rights reserved.
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package sync
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ticketLock grants the lock in the order it's requested: every caller takes a ticket
+// and waits until its number is served, so no one is starved.
+type ticketLock struct {
+	next    atomic.Uint32
+	_       [60]byte // keep the counters off each other's cache lines
+	serving atomic.Uint32
+}
+
+func (tl *ticketLock) Lock() {
+	ticket := tl.next.Add(1) - 1
+	for {
+		serving := tl.serving.Load()
+		if serving == ticket {
+			return
+		}
+		// Back off in proportion to the number of callers ahead.
+		for i := ticket - serving; i > 0; i-- {
+			runtime.Gosched()
+		}
+	}
+}
+
+func (tl *ticketLock) Unlock() {
+	tl.serving.Add(1)
+}
+
+// NewTicketLock instantiates a ticket lock, which is fair to the callers, they acquire
+// the lock in the order they arrived.
+func NewTicketLock() sync.Locker {
+	return new(ticketLock)
+}
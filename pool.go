@@ -35,12 +35,87 @@ type Pool struct {
 // Pool.Submit() call once the current Pool runs out of its capacity, and to avoid this,
 // you should instantiate a Pool with ants.WithNonblocking(true).
 func (p *Pool) Submit(task func()) error {
+	return p.submit(task, nil)
+}
+
+// submit submits a task along with the fault handed over by the multi-pool, see retrieveWeightedWorker.
+func (p *Pool) submit(task func(), f *fault) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	id, submitted := p.identify()
+	w, err := p.retrieveWorker(f)
+	if w != nil {
+		w.tracked().submit(id, submitted)
+		w.inputFunc(task)
+	}
+	return err
+}
+
+// SubmitTask is like Submit but describes the task with the given options,
+// the description is reported by Pool.InFlight() and the slow task watchdog.
+func (p *Pool) SubmitTask(task func(), opts ...TaskOption) error {
+	info := p.newTaskInfo()
+	for _, opt := range opts {
+		opt(info)
+	}
+	return p.submitTask(task, info)
+}
+
+// submitTask submits a task along with its description.
+func (p *Pool) submitTask(task func(), info *TaskInfo) error {
+	return p.submitTrackedTask(task, info, nil)
+}
+
+// submitTrackedTask is like submitTask, and sets tracker to the tracker of the worker
+// before handing the task over if tracker is not nil.
+func (p *Pool) submitTrackedTask(task func(), info *TaskInfo, tracker **taskTracker) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	w, err := p.retrieveWeightedWorker(info.Tenant, 1, nil)
+	if w != nil {
+		w.tracked().task = info
+		if tracker != nil {
+			*tracker = w.tracked()
+		}
+		w.inputFunc(task)
+	}
+	return err
+}
+
+// SubmitErr submits a task that returns an error to the pool.
+//
+// The error returned by the task is passed to the ErrorHandler if there is one,
+// and collected to be returned by Pool.Wait().
+func (p *Pool) SubmitErr(task func() error) error {
+	info := p.newTaskInfo()
+	p.taskErrs.add()
+	err := p.submitTask(func() {
+		var err error
+		defer func() {
+			p.taskDone(err, *info)
+		}()
+		err = task()
+	}, info)
+	if err != nil {
+		p.taskErrs.done(nil)
+	}
+	return err
+}
+
+// trySubmit is like submit but never blocks, it returns ErrPoolOverload if there is no available worker.
+func (p *Pool) trySubmit(task func(), f *fault) error {
 	if p.IsClosed() {
 		return ErrPoolClosed
 	}
 
-	w, err := p.retrieveWorker()
+	id, submitted := p.identify()
+	w, err := p.tryRetrieveWorker(f)
 	if w != nil {
+		w.tracked().submit(id, submitted)
 		w.inputFunc(task)
 	}
 	return err
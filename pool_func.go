@@ -37,12 +37,34 @@ type PoolWithFunc struct {
 // Pool.Invoke() call once the current Pool runs out of its capacity, and to avoid this,
 // you should instantiate a PoolWithFunc with ants.WithNonblocking(true).
 func (p *PoolWithFunc) Invoke(arg any) error {
+	return p.invoke(arg, nil)
+}
+
+// invoke passes the argument along with the fault handed over by the multi-pool, see retrieveWeightedWorker.
+func (p *PoolWithFunc) invoke(arg any, f *fault) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	id, submitted := p.identify()
+	w, err := p.retrieveWorker(f)
+	if w != nil {
+		w.tracked().submit(id, submitted)
+		w.inputArg(arg)
+	}
+	return err
+}
+
+// tryInvoke is like invoke but never blocks, it returns ErrPoolOverload if there is no available worker.
+func (p *PoolWithFunc) tryInvoke(arg any, f *fault) error {
 	if p.IsClosed() {
 		return ErrPoolClosed
 	}
 
-	w, err := p.retrieveWorker()
+	id, submitted := p.identify()
+	w, err := p.tryRetrieveWorker(f)
 	if w != nil {
+		w.tracked().submit(id, submitted)
 		w.inputArg(arg)
 	}
 	return err
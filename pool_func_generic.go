@@ -28,21 +28,83 @@ type PoolWithFuncGeneric[T any] struct {
 
 	// fn is the unified function for processing tasks.
 	fn func(T)
+
+	// fnErr is the error-returning function for processing tasks, it's set by NewPoolWithFuncGenericErr
+	// and takes the place of fn.
+	fnErr func(T) error
 }
 
 // Invoke passes the argument to the pool to start a new task.
 func (p *PoolWithFuncGeneric[T]) Invoke(arg T) error {
+	return p.invoke(arg, nil)
+}
+
+// invoke passes the argument along with the fault handed over by the multi-pool, see retrieveWeightedWorker.
+func (p *PoolWithFuncGeneric[T]) invoke(arg T, f *fault) error {
 	if p.IsClosed() {
 		return ErrPoolClosed
 	}
 
-	w, err := p.retrieveWorker()
-	if w != nil {
-		w.(*goWorkerWithFuncGeneric[T]).arg <- arg
+	id, submitted, info := p.describe(arg)
+	w, err := p.retrieveWorker(f)
+	p.handOver(w, arg, id, submitted, info)
+	return err
+}
+
+// runTask calls the pool function with the argument on the worker goroutine that tracks the task with t.
+func (p *PoolWithFuncGeneric[T]) runTask(t *taskTracker, arg T) {
+	if p.fnErr == nil {
+		p.fn(arg)
+		return
+	}
+	info := t.task
+	var err error
+	defer func() {
+		p.taskDone(err, *info)
+	}()
+	err = p.fnErr(arg)
+}
+
+// tryInvoke is like invoke but never blocks, it returns ErrPoolOverload if there is no available worker.
+func (p *PoolWithFuncGeneric[T]) tryInvoke(arg T, f *fault) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
 	}
+
+	id, submitted, info := p.describe(arg)
+	w, err := p.tryRetrieveWorker(f)
+	p.handOver(w, arg, id, submitted, info)
 	return err
 }
 
+// describe identifies the task of the argument before a worker is retrieved for it. The task of a pool
+// made by NewPoolWithFuncGenericErr is described by info and counted by Wait, see handOver.
+func (p *PoolWithFuncGeneric[T]) describe(arg T) (id uint64, submitted int64, info *TaskInfo) {
+	if p.fnErr == nil {
+		id, submitted = p.identify()
+		return
+	}
+	info = p.newTaskInfo()
+	info.Arg = arg
+	p.taskErrs.add()
+	return
+}
+
+// handOver hands the task described by describe over to the retrieved worker,
+// or uncounts it from Wait if there is no worker.
+func (p *PoolWithFuncGeneric[T]) handOver(w worker, arg T, id uint64, submitted int64, info *TaskInfo) {
+	if w == nil {
+		if info != nil {
+			p.taskErrs.done(nil)
+		}
+		return
+	}
+	t := w.tracked()
+	t.submit(id, submitted)
+	t.task = info
+	w.(*goWorkerWithFuncGeneric[T]).arg <- arg
+}
+
 // NewPoolWithFuncGeneric instantiates a PoolWithFuncGeneric[T] with customized options.
 func NewPoolWithFuncGeneric[T any](size int, pf func(T), options ...Option) (*PoolWithFuncGeneric[T], error) {
 	if pf == nil {
@@ -69,3 +131,21 @@ func NewPoolWithFuncGeneric[T any](size int, pf func(T), options ...Option) (*Po
 
 	return pool, nil
 }
+
+// NewPoolWithFuncGenericErr instantiates a PoolWithFuncGeneric[T] with an error-returning function.
+//
+// The error returned by the function is passed to the ErrorHandler if there is one,
+// and collected to be returned by PoolWithFuncGeneric.Wait().
+func NewPoolWithFuncGenericErr[T any](size int, pf func(T) error, options ...Option) (*PoolWithFuncGeneric[T], error) {
+	if pf == nil {
+		return nil, ErrLackPoolFunc
+	}
+
+	pool, err := NewPoolWithFuncGeneric(size, func(T) {}, options...)
+	if err != nil {
+		return nil, err
+	}
+	pool.fnErr = pf
+
+	return pool, nil
+}
@@ -0,0 +1,67 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolWithFuncGenericErrTryInvoke(t *testing.T) {
+	errFoo := errors.New("foo")
+	block := make(chan struct{})
+	infos := make(chan TaskInfo, 1)
+	p, err := NewPoolWithFuncGenericErr(1, func(i int) error {
+		if i < 0 {
+			<-block
+			return nil
+		}
+		return errFoo
+	}, WithErrorHandler(func(_ error, info TaskInfo) {
+		infos <- info
+	}))
+	require.NoError(t, err)
+	defer p.Release()
+
+	// The tasks handed over by tryInvoke are described and collected like the invoked ones.
+	require.NoError(t, p.tryInvoke(7, nil))
+	var info TaskInfo
+	select {
+	case info = <-infos:
+	case <-time.After(time.Second):
+		t.Fatal("the error of the task is not handled")
+	}
+	require.NotZero(t, info.ID)
+	require.False(t, info.SubmitTime.IsZero())
+	require.EqualValues(t, 7, info.Arg)
+	require.ErrorIs(t, p.Wait(), errFoo)
+
+	// A task failed to be handed over doesn't block Wait().
+	require.NoError(t, p.tryInvoke(-1, nil))
+	require.ErrorIs(t, p.tryInvoke(8, nil), ErrPoolOverload)
+	close(block)
+	require.NoError(t, p.Wait())
+}
@@ -0,0 +1,107 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket is a token bucket that refills at rate tokens per second, up to burst tokens.
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	if rate <= 0 || math.IsInf(rate, 1) {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// reserve takes a token from the bucket and returns how long to wait until the token is available.
+// If wait is false, it only takes the token that is available at once, otherwise it returns false.
+func (tb *tokenBucket) reserve(now time.Time, wait bool) (time.Duration, bool) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = math.Min(tb.burst, tb.tokens+elapsed.Seconds()*tb.rate)
+		tb.last = now
+	}
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0, true
+	}
+	if !wait {
+		return 0, false
+	}
+	// Tokens owed to the earlier callers make the later ones wait longer.
+	tb.tokens--
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second)), true
+}
+
+// refund gives back the token taken by reserve that is not used.
+func (tb *tokenBucket) refund() {
+	tb.mu.Lock()
+	tb.tokens = math.Min(tb.burst, tb.tokens+1)
+	tb.mu.Unlock()
+}
+
+// waitRateLimit blocks until the rate limit of the pool admits a task, it never blocks if block is false.
+// The caller gives up waiting as soon as the pool is closed, and the token it has reserved is given back.
+// Like retrieveWorker, it fails fast with ErrRateLimited in nonblocking mode or when the number of
+// the blocked callers reaches MaxBlockingTasks.
+func (p *poolCommon) waitRateLimit(block bool) error {
+	block = block && !p.options.Nonblocking &&
+		(p.options.MaxBlockingTasks == 0 || p.Waiting() < p.options.MaxBlockingTasks)
+	d, ok := p.limiter.reserve(p.clock.Now(), block)
+	if !ok {
+		p.rateLimited.Add(1)
+		return ErrRateLimited
+	}
+	if d > 0 {
+		p.addWaiting(1)
+		ok = p.sleepUnlessClosed(d)
+		p.addWaiting(-1)
+		if !ok || p.IsClosed() {
+			p.limiter.refund()
+			return ErrPoolClosed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultRetryBackoff is the default backoff before the first retry.
+	DefaultRetryBackoff = 100 * time.Millisecond
+
+	// DefaultRetryMultiplier is the default factor by which the backoff grows after each retry.
+	DefaultRetryMultiplier = 2.0
+)
+
+// RetryPolicy describes how a failed task is retried by Pool.SubmitWithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the task runs, including the first run.
+	// A value less than 1 is treated as 1, which means the task is never retried.
+	MaxAttempts int
+
+	// InitialBackoff is the backoff before the first retry, DefaultRetryBackoff is used if it's not positive.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the backoff between two attempts, the backoff is unbounded if it's not positive.
+	MaxBackoff time.Duration
+
+	// Multiplier is the factor by which the backoff grows after each retry,
+	// DefaultRetryMultiplier is used if it's less than 1.
+	Multiplier float64
+
+	// Jitter is the fraction in [0, 1] of each backoff that is randomized,
+	// e.g. 0.2 makes the actual backoff a random value in [0.8*backoff, backoff].
+	Jitter float64
+
+	// Retryable reports whether the task should be retried after it returns err,
+	// all errors are retryable if it's nil.
+	Retryable func(err error) bool
+
+	// RetryOnPanic indicates whether a panic in the task counts as a retryable failure.
+	// If it's true, the panic is recovered and turned into an error wrapping ErrTaskPanicked,
+	// otherwise the panic is handled by the pool as usual and the task is not retried.
+	RetryOnPanic bool
+}
+
+func (rp *RetryPolicy) maxAttempts() int {
+	if rp.MaxAttempts < 1 {
+		return 1
+	}
+	return rp.MaxAttempts
+}
+
+func (rp *RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrTaskPanicked) {
+		return rp.RetryOnPanic
+	}
+	return rp.Retryable == nil || rp.Retryable(err)
+}
+
+// backoff returns the duration to wait after the given attempt before the next one.
+func (rp *RetryPolicy) backoff(attempt int) time.Duration {
+	initial := rp.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultRetryBackoff
+	}
+	multiplier := rp.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultRetryMultiplier
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if rp.MaxBackoff > 0 && d > float64(rp.MaxBackoff) {
+		d = float64(rp.MaxBackoff)
+	}
+	if d > math.MaxInt64 {
+		d = math.MaxInt64
+	}
+	if jitter := math.Min(math.Max(rp.Jitter, 0), 1); jitter > 0 {
+		d -= d * jitter * rand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// SubmitWithRetry submits a task that returns an error to the pool and retries it according to the policy.
+//
+// Between two attempts the task is re-submitted to the pool after a backoff instead of
+// sleeping inside a worker, so a task that is backing off doesn't hold any capacity of the pool.
+// Only the final result of the task is passed to the ErrorHandler and collected by Pool.Wait(),
+// if a retry can't be submitted, e.g. the pool has been closed, the submission error is joined
+// with the last error of the task as the final result.
+func (p *Pool) SubmitWithRetry(task func() error, policy RetryPolicy) error {
+	info := p.newTaskInfo()
+	p.taskErrs.add()
+	err := p.submitTask(p.retryTask(task, &policy, info, 1), info)
+	if err != nil {
+		p.taskErrs.done(nil)
+	}
+	return err
+}
+
+// retryTask wraps the task so that it's re-submitted after a backoff if the given attempt fails.
+func (p *Pool) retryTask(task func() error, policy *RetryPolicy, info *TaskInfo, attempt int) func() {
+	return func() {
+		var (
+			err   error
+			retry bool
+		)
+		defer func() {
+			if !retry {
+				p.taskDone(err, *info)
+			}
+		}()
+
+		err = p.runAttempt(task, policy)
+		if err == nil || attempt >= policy.maxAttempts() || !policy.retryable(err) {
+			return
+		}
+
+		retry = true
+		p.retried.Add(1)
+		p.afterFunc(policy.backoff(attempt), func() {
+			if serr := p.submitTask(p.retryTask(task, policy, info, attempt+1), info); serr != nil {
+				p.taskDone(errors.Join(serr, err), *info)
+			}
+		})
+	}
+}
+
+// runAttempt runs the task once, turning a panic into an error if the policy retries on panics.
+func (p *Pool) runAttempt(task func() error, policy *RetryPolicy) (err error) {
+	if policy.RetryOnPanic {
+		defer func() {
+			if r := recover(); r != nil {
+				p.panicked.Add(1)
+				err = fmt.Errorf("%w: %v", ErrTaskPanicked, r)
+			}
+		}()
+	}
+	return task()
+}
@@ -0,0 +1,214 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shared is the runtime shared by the pools with SharedRuntime, one clock goroutine updates
+// the current time of all the pools and one scavenger goroutine purges their stale workers.
+// The goroutines are started on demand and exit once there is no pool left.
+var shared = &sharedRuntime{
+	clocked: make(map[*poolCommon]struct{}),
+	wake:    make(chan struct{}, 1),
+}
+
+type sharedRuntime struct {
+	mu sync.Mutex
+
+	clocked   map[*poolCommon]struct{}
+	ticking   bool
+	scavenged purgeQueue
+	scanning  bool
+	wake      chan struct{} // reschedules the scavenger after the queue changes
+}
+
+// purgeEntry is a pool scheduled to be purged by the shared scavenger.
+type purgeEntry struct {
+	pool  *poolCommon
+	next  time.Time
+	index int
+}
+
+// purgeQueue is a min-heap of the pools ordered by the time they're due to be purged.
+type purgeQueue []*purgeEntry
+
+func (q purgeQueue) Len() int           { return len(q) }
+func (q purgeQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q purgeQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *purgeQueue) Push(x any) {
+	e := x.(*purgeEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *purgeQueue) Pop() any {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return e
+}
+
+// addClocked registers the pool with the shared clock, it returns the function to unregister the pool,
+// which cancels the context of the pool as well.
+func (rt *sharedRuntime) addClocked(p *poolCommon, cancel context.CancelFunc) (stop context.CancelFunc) {
+	rt.mu.Lock()
+	rt.clocked[p] = struct{}{}
+	if !rt.ticking {
+		rt.ticking = true
+		go rt.ticktock()
+	}
+	rt.mu.Unlock()
+
+	return func() {
+		cancel()
+		rt.mu.Lock()
+		delete(rt.clocked, p)
+		rt.mu.Unlock()
+		atomic.StoreInt32(&p.ticktockDone, 1)
+	}
+}
+
+// addScavenged registers the pool with the shared scavenger, it returns the function to unregister the pool,
+// which cancels the context of the pool as well.
+func (rt *sharedRuntime) addScavenged(p *poolCommon, cancel context.CancelFunc) (stop context.CancelFunc) {
+	e := &purgeEntry{pool: p, next: time.Now().Add(p.options.ExpiryDuration)}
+	rt.mu.Lock()
+	heap.Push(&rt.scavenged, e)
+	if !rt.scanning {
+		rt.scanning = true
+		go rt.scavenge()
+	}
+	rt.mu.Unlock()
+	rt.notify()
+
+	return func() {
+		cancel()
+		rt.mu.Lock()
+		// The scavenger holds the lock while purging, so the pool is never purged after this.
+		heap.Remove(&rt.scavenged, e.index)
+		rt.mu.Unlock()
+		rt.notify()
+		atomic.StoreInt32(&p.purgeDone, 1)
+	}
+}
+
+func (rt *sharedRuntime) notify() {
+	select {
+	case rt.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ticktock updates the current time in all the registered pools regularly.
+func (rt *sharedRuntime) ticktock() {
+	ticker := time.NewTicker(nowTimeUpdateInterval)
+	defer ticker.Stop()
+
+	var pools []*poolCommon
+	for range ticker.C {
+		rt.mu.Lock()
+		if len(rt.clocked) == 0 {
+			rt.ticking = false
+			rt.mu.Unlock()
+			return
+		}
+		pools = pools[:0]
+		for p := range rt.clocked {
+			pools = append(pools, p)
+		}
+		rt.mu.Unlock()
+
+		// The handlers of slow tasks are called without holding the lock, they might release the pools.
+		now := time.Now()
+		for i, p := range pools {
+			if !p.IsClosed() {
+				p.tick(now)
+			}
+			pools[i] = nil
+		}
+	}
+}
+
+// scavenge purges the stale workers of every registered pool as per the ExpiryDuration of the pool.
+func (rt *sharedRuntime) scavenge() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	var pools []*poolCommon
+	for {
+		rt.mu.Lock()
+		if len(rt.scavenged) == 0 {
+			rt.scanning = false
+			rt.mu.Unlock()
+			return
+		}
+		pools = pools[:0]
+		now := time.Now()
+		for len(rt.scavenged) > 0 && !rt.scavenged[0].next.After(now) {
+			e := rt.scavenged[0]
+			pools = append(pools, e.pool)
+			e.next = now.Add(e.pool.options.ExpiryDuration)
+			heap.Fix(&rt.scavenged, 0)
+		}
+		d := time.Duration(0)
+		if len(rt.scavenged) > 0 {
+			d = rt.scavenged[0].next.Sub(now)
+		}
+		rt.mu.Unlock()
+
+		// The stale workers are purged without holding the lock, as purging them signals the workers
+		// and logs, which must not block the other pools from being registered or released.
+		for i, p := range pools {
+			if !p.IsClosed() {
+				p.purgeStale()
+			}
+			pools[i] = nil
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(d)
+		select {
+		case <-timer.C:
+		case <-rt.wake:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the current goroutine, parsed from the header of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// goroutineStacks returns the stack traces of all goroutines.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// findGoroutineStack returns the stack trace of the goroutine with the given ID from
+// the stack traces of all goroutines, or nil if the goroutine is not found.
+func findGoroutineStack(stacks []byte, id uint64) []byte {
+	header := strconv.AppendUint(append([]byte(nil), goroutinePrefix...), id, 10)
+	header = append(header, " ["...)
+	for len(stacks) > 0 {
+		var stack []byte
+		if i := bytes.Index(stacks, []byte("\n\n")); i >= 0 {
+			stack, stacks = stacks[:i+1], stacks[i+2:]
+		} else {
+			stack, stacks = stacks, nil
+		}
+		if bytes.HasPrefix(stack, header) {
+			return stack
+		}
+	}
+	return nil
+}
+
+// goroutineStack returns the stack trace of the goroutine with the given ID,
+// or nil if the goroutine doesn't exist anymore.
+func goroutineStack(id uint64) []byte {
+	return findGoroutineStack(goroutineStacks(), id)
+}
@@ -0,0 +1,126 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// TaskInfo describes a task that has been submitted to a pool.
+type TaskInfo struct {
+	// ID is the identifier of the task, which is unique within the pool.
+	ID uint64
+
+	// Arg is the argument of the task for PoolWithFuncGeneric, it's nil for Pool.
+	Arg any
+
+	// Name is the name of the task, see WithTaskName.
+	Name string
+
+	// Labels are the labels of the task, see WithTaskLabels.
+	Labels map[string]string
+
+	// Tenant is the tenant the task is submitted on behalf of, see Pool.SubmitForTenant.
+	Tenant string
+
+	// SubmitTime is the time the task was submitted.
+	SubmitTime time.Time
+
+	// StartTime is the time the task started running, it's only set for running tasks.
+	StartTime time.Time
+
+	// GoroutineID is the ID of the worker goroutine running the task, it's only set for running tasks.
+	GoroutineID uint64
+}
+
+// WorkerInfo describes an idle worker of a pool.
+type WorkerInfo struct {
+	// GoroutineID is the ID of the worker goroutine.
+	GoroutineID uint64
+
+	// LastUsed is the time the worker was put back into the pool.
+	LastUsed time.Time
+}
+
+// TaskOption represents the optional function to describe a task.
+type TaskOption func(info *TaskInfo)
+
+// WithTaskName sets up the name of a task.
+func WithTaskName(name string) TaskOption {
+	return func(info *TaskInfo) {
+		info.Name = name
+	}
+}
+
+// WithTaskLabels sets up the labels of a task.
+func WithTaskLabels(labels map[string]string) TaskOption {
+	return func(info *TaskInfo) {
+		info.Labels = labels
+	}
+}
+
+// taskErrors keeps track of the error-returning tasks and collects their errors.
+type taskErrors struct {
+	lock    sync.Mutex
+	cond    *sync.Cond
+	pending int
+	errs    []error
+}
+
+func newTaskErrors() *taskErrors {
+	te := &taskErrors{}
+	te.cond = sync.NewCond(&te.lock)
+	return te
+}
+
+func (te *taskErrors) add() {
+	te.lock.Lock()
+	te.pending++
+	te.lock.Unlock()
+}
+
+func (te *taskErrors) done(err error) {
+	te.lock.Lock()
+	if err != nil {
+		te.errs = append(te.errs, err)
+	}
+	te.pending--
+	if te.pending == 0 {
+		te.cond.Broadcast()
+	}
+	te.lock.Unlock()
+}
+
+// wait blocks until all pending tasks are done, then returns and resets the collected errors.
+func (te *taskErrors) wait() error {
+	te.lock.Lock()
+	defer te.lock.Unlock()
+	for te.pending > 0 {
+		te.cond.Wait()
+	}
+	err := errors.Join(te.errs...)
+	te.errs = nil
+	return err
+}
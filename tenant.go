@@ -0,0 +1,168 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+// TenantQuota is the quota of a tenant sharing a pool, see WithTenantQuota.
+type TenantQuota struct {
+	// MaxConcurrency is the maximum number of the capacity units the tasks of the tenant can take,
+	// 0 means there is no limit other than the capacity of the pool.
+	MaxConcurrency int
+
+	// Reserved is the number of the capacity units reserved for the tenant,
+	// they're never taken by the other tenants even if the tenant is idle.
+	Reserved int
+
+	// Weight is the share of the tenant when the pool is saturated, the tenants are served
+	// in proportion to their weights. The weight less than 1 is treated as 1.
+	Weight int
+}
+
+// TenantStats is a snapshot of the statistics of a tenant sharing a pool.
+type TenantStats struct {
+	// Running is the number of the capacity units taken by the tasks of the tenant.
+	Running int
+
+	// Waiting is the number of the tasks of the tenant blocked on submission.
+	Waiting int
+
+	// Admitted is the number of the tasks of the tenant that have been admitted into the pool.
+	Admitted uint64
+
+	// Rejected is the number of the tasks of the tenant that failed to be admitted.
+	Rejected uint64
+}
+
+// tenantState is the state of a tenant, it's guarded by the lock of the pool.
+type tenantState struct {
+	name     string
+	max      int
+	reserved int
+	weight   int
+
+	// pins is the number of the holders keeping the tenant even if it's idle, i.e. the quota,
+	// the pool itself for the default tenant, and the child pools borrowing on its behalf, see evictLocked.
+	pins int
+
+	running  int
+	waiting  int
+	admitted uint64
+	rejected uint64
+
+	// finish is the virtual finish time of the last task of the tenant in the queue.
+	finish float64
+}
+
+func newTenantState(name string, quota TenantQuota) *tenantState {
+	return &tenantState{
+		name:     name,
+		max:      max(0, quota.MaxConcurrency),
+		reserved: max(0, quota.Reserved),
+		weight:   max(1, quota.Weight),
+	}
+}
+
+// owed returns the capacity units reserved for the tenant but not taken by it.
+func (ts *tenantState) owed() int {
+	return max(0, ts.reserved-ts.running)
+}
+
+// SubmitForTenant submits a task on behalf of the tenant.
+//
+// The tenants sharing the pool are isolated by their quotas set up with WithTenantQuota: the capacity
+// of the pool is the outer bound of all tasks, while the tasks of a tenant never take more than its
+// MaxConcurrency, nor the capacity units reserved for the other tenants. Once the pool is saturated,
+// the blocked tasks are scheduled by weighted fair queueing, so that every tenant is served in
+// proportion to its weight. The tasks submitted by the other methods belong to the tenant "".
+func (p *Pool) SubmitForTenant(tenant string, task func()) error {
+	info := p.newTaskInfo()
+	info.Tenant = tenant
+	return p.submitTask(task, info)
+}
+
+// TenantStats returns the snapshots of the statistics of the tenants sharing this pool,
+// keyed by the names of the tenants.
+//
+// The tenants without quotas are only reported while they have tasks running or waiting,
+// their statistics are dropped once they're idle.
+func (p *poolCommon) TenantStats() map[string]TenantStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	stats := make(map[string]TenantStats, len(p.tenants))
+	running := 0
+	for name, ts := range p.tenants {
+		stats[name] = TenantStats{
+			Running:  ts.running,
+			Waiting:  ts.waiting,
+			Admitted: ts.admitted,
+			Rejected: ts.rejected,
+		}
+		running += ts.running
+	}
+	if p.lockFree {
+		// The tasks admitted by the lock-free fast path are on behalf of the default tenant.
+		st := stats[""]
+		st.Running += max(0, int(p.inUse.Load())-running)
+		st.Admitted += p.fastAdmitted.Load()
+		stats[""] = st
+	}
+	return stats
+}
+
+// initTenants sets up the tenants with the quotas from the options.
+func (p *poolCommon) initTenants() {
+	p.tenants = make(map[string]*tenantState, len(p.options.Tenants)+1)
+	for name, quota := range p.options.Tenants {
+		ts := newTenantState(name, quota)
+		ts.pins++
+		p.tenants[name] = ts
+		p.owed += ts.owed()
+	}
+	if p.defaultTenant = p.tenants[""]; p.defaultTenant == nil {
+		p.defaultTenant = newTenantState("", TenantQuota{})
+		p.defaultTenant.pins++
+		p.tenants[""] = p.defaultTenant
+	}
+}
+
+// tenantLocked returns the state of the tenant, it must be called with p.lock held.
+// The tenants without quotas are set up on the first use, and evicted once they're idle.
+func (p *poolCommon) tenantLocked(name string) *tenantState {
+	if name == "" {
+		return p.defaultTenant
+	}
+	ts := p.tenants[name]
+	if ts == nil {
+		ts = newTenantState(name, TenantQuota{})
+		p.tenants[name] = ts
+	}
+	return ts
+}
+
+// evictLocked forgets the tenant without a quota once it has no task running or waiting,
+// so that the tenants set up on the first use don't pile up, it must be called with p.lock held.
+func (p *poolCommon) evictLocked(ts *tenantState) {
+	if ts.pins == 0 && ts.running == 0 && ts.waiting == 0 && p.tenants[ts.name] == ts {
+		delete(p.tenants, ts.name)
+	}
+}
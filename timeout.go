@@ -0,0 +1,95 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// taskOverrunGrace is the time given to a task to return after its context is done,
+// before the task is regarded as overrunning its timeout.
+const taskOverrunGrace = 10 * time.Millisecond
+
+// overrunDumpInterval is the minimum interval between the dumps of the goroutine stacks
+// for the overrunning tasks, as the dump stops the world.
+const overrunDumpInterval = time.Second
+
+// SubmitWithTimeout submits a task that is canceled cooperatively after the timeout.
+//
+// The task is given a context that is done once the timeout elapses after the task starts running,
+// it's up to the task to return in time when the context is done. The worker is occupied until the
+// task actually returns, a task still running shortly after its timeout is counted in Stats.Overrun and
+// passed to the TaskOverrunHandler along with its stack trace, which helps to find the tasks that
+// ignore the context. Capturing the stack trace stops the world, so it's done at most once per second
+// in a pool, the stack trace is nil for the tasks overrunning in the meantime.
+func (p *Pool) SubmitWithTimeout(timeout time.Duration, task func(ctx context.Context)) error {
+	var (
+		info    = p.newTaskInfo()
+		tracker *taskTracker
+	)
+	return p.submitTrackedTask(func() {
+		ctx, cancel := p.withTimeout(timeout)
+		defer cancel()
+
+		var (
+			done atomic.Bool
+			gid  uint64
+		)
+		if p.options.TaskOverrunHandler != nil {
+			gid = tracker.goroutineID()
+		}
+		stop := p.afterFunc(timeout+taskOverrunGrace, func() {
+			if done.Load() {
+				return
+			}
+			p.overrun.Add(1)
+			if oh := p.options.TaskOverrunHandler; oh != nil {
+				var stack []byte
+				if p.allowOverrunDump() {
+					stack = goroutineStack(gid)
+				}
+				// Don't report the stack of the next task if this one has returned during the capture.
+				if done.Load() {
+					stack = nil
+				}
+				oh(*info, timeout, stack)
+			}
+		})
+		defer func() {
+			done.Store(true)
+			stop()
+		}()
+
+		task(ctx)
+	}, info, &tracker)
+}
+
+// allowOverrunDump reports whether the goroutine stacks can be dumped for an overrunning task,
+// see overrunDumpInterval.
+func (p *Pool) allowOverrunDump() bool {
+	now := p.clock.Now().UnixNano()
+	last := p.overrunDumped.Load()
+	return (last == 0 || now-last >= int64(overrunDumpInterval)) && p.overrunDumped.CompareAndSwap(last, now)
+}
@@ -0,0 +1,308 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// taskTracker records the task that a worker is running, it's embedded in every kind of worker.
+//
+// The task is only published to the other goroutines while the pool is tracked, see poolCommon.tracked.
+// The published fields are written by the worker goroutine and read by the others, seq works as
+// a sequence lock to keep the reads consistent: it's odd while the tracker is being updated.
+type taskTracker struct {
+	seq   atomic.Uint64
+	start atomic.Int64 // the time in nanoseconds the current task started, 0 if the worker is idle
+	info  atomic.Pointer[TaskInfo]
+
+	// id and submitted identify the current task that was submitted without a description,
+	// submitted is the time in nanoseconds the task was submitted.
+	id        atomic.Uint64
+	submitted atomic.Int64
+
+	// gid is the ID of the worker goroutine, 0 if it's not resolved yet, see taskTracker.goroutineID.
+	gid atomic.Uint64
+
+	// registered indicates whether the tracker is in poolCommon.trackers, it's only accessed by the worker goroutine.
+	registered bool
+
+	// task is the information of the next or the current task, it's set by the submitter before
+	// handing the task over, and cleared by the worker goroutine once the task ends.
+	task *TaskInfo
+
+	// taskID and taskSubmitted identify the task in place of task if it has no description,
+	// they're set by the submitter along with task.
+	taskID        uint64
+	taskSubmitted int64
+
+	// reported is the sequence of the last task reported by the watchdog.
+	reported atomic.Uint64
+
+	// weight is the capacity units the current task consumes on behalf of the tenant,
+	// they're set by the submitter along with task.
+	weight int
+	tenant *tenantState
+
+	// fault is the fault injected into the current task, it's set by the submitter along with task.
+	fault fault
+
+	// probe indicates whether the current task is the probe of the panic circuit breaker,
+	// it's set by the submitter along with task.
+	probe bool
+}
+
+// publish publishes the start of the current task, it's called by the worker goroutine.
+func (t *taskTracker) publish(now time.Time) {
+	t.seq.Add(1)
+	t.info.Store(t.task)
+	t.id.Store(t.taskID)
+	t.submitted.Store(t.taskSubmitted)
+	t.start.Store(now.UnixNano())
+	t.seq.Add(1)
+}
+
+// submit identifies the next task that is handed over without a description,
+// it's called by the submitter before handing the task over.
+func (t *taskTracker) submit(id uint64, submitted int64) {
+	t.taskID, t.taskSubmitted = id, submitted
+}
+
+// end marks the end of a task, it's called by the worker goroutine.
+func (t *taskTracker) end() {
+	if t.start.Load() != 0 {
+		t.seq.Add(1)
+		t.start.Store(0)
+		t.info.Store(nil)
+		t.seq.Add(1)
+	}
+	t.task, t.taskID, t.taskSubmitted = nil, 0, 0
+}
+
+// current returns the information of the current task, it's called by the worker goroutine.
+func (t *taskTracker) current() (info TaskInfo) {
+	if t.task != nil {
+		info = *t.task
+	} else {
+		info.ID = t.taskID
+		if t.taskSubmitted != 0 {
+			info.SubmitTime = time.Unix(0, t.taskSubmitted)
+		}
+	}
+	if start := t.start.Load(); start != 0 {
+		info.StartTime = time.Unix(0, start)
+	}
+	info.GoroutineID = t.gid.Load()
+	return
+}
+
+// load returns a consistent snapshot of the current task, ok is false if the worker is idle
+// or the task is not published. The returned seq identifies the task, as it's changed every time
+// a task begins or ends.
+func (t *taskTracker) load() (seq uint64, info TaskInfo, ok bool) {
+	for {
+		seq = t.seq.Load()
+		if seq&1 == 1 {
+			continue
+		}
+		start, ti := t.start.Load(), t.info.Load()
+		id, submitted := t.id.Load(), t.submitted.Load()
+		if t.seq.Load() != seq {
+			continue
+		}
+		if start == 0 {
+			return seq, info, false
+		}
+		if ti != nil {
+			info = *ti
+		} else {
+			info.ID = id
+			if submitted != 0 {
+				info.SubmitTime = time.Unix(0, submitted)
+			}
+		}
+		info.StartTime = time.Unix(0, start)
+		info.GoroutineID = t.gid.Load()
+		return seq, info, true
+	}
+}
+
+// identify returns the ID and the submit time in nanoseconds of a task submitted without a description,
+// both are 0 unless the pool is tracked as nobody would see them.
+func (p *poolCommon) identify() (id uint64, submitted int64) {
+	if !p.tracked.Load() {
+		return 0, 0
+	}
+	return p.nextTaskID(), p.nowTime().UnixNano()
+}
+
+// goroutineID returns the ID of the worker goroutine, which is resolved on the first call
+// and kept until the goroutine exits, it's called by the worker goroutine.
+func (t *taskTracker) goroutineID() uint64 {
+	id := t.gid.Load()
+	if id == 0 {
+		id = goroutineID()
+		t.gid.Store(id)
+	}
+	return id
+}
+
+// register resolves the goroutine ID of a worker and registers its tracker, it's called
+// by the worker goroutine once the pool is tracked.
+func (p *poolCommon) register(t *taskTracker) {
+	t.goroutineID()
+	t.registered = true
+	p.trackersLock.Lock()
+	p.trackers[t] = struct{}{}
+	p.trackersLock.Unlock()
+}
+
+// track is called by a worker goroutine that has just started.
+func (p *poolCommon) track(t *taskTracker) {
+	if p.tracked.Load() {
+		p.register(t)
+	}
+}
+
+// beginTask marks the start of a task on the worker goroutine.
+//
+// The start time is read from the clock rather than the time kept by the pool, which lags behind
+// by up to 500 milliseconds, so the clock is only read while the pool is tracked.
+func (p *poolCommon) beginTask(t *taskTracker) {
+	if p.tracked.Load() {
+		if !t.registered {
+			p.register(t)
+		}
+		t.publish(p.clock.Now())
+	}
+	if t.fault != (fault{}) {
+		p.injectTaskFault(t)
+	}
+}
+
+// untrack is called by a worker goroutine that is exiting.
+func (p *poolCommon) untrack(t *taskTracker) {
+	t.end()
+	t.probe = false
+	if t.weight > 0 {
+		p.lock.Lock()
+		p.releaseWeightLocked(t)
+		p.signalLocked()
+		p.lock.Unlock()
+	}
+	if t.registered {
+		p.trackersLock.Lock()
+		delete(p.trackers, t)
+		p.trackersLock.Unlock()
+		t.registered = false
+	}
+	t.gid.Store(0)
+}
+
+// InFlight returns a snapshot of the tasks currently running in this pool.
+//
+// Every task is reported with its ID and submit time, the names and labels are only available for
+// the tasks submitted along with their descriptions, e.g. by Pool.SubmitTask().
+//
+// Tracking the running tasks is not free, so it's deferred until the pool is inspected for the first
+// time, either by this method or Pool.IdleWorkers(), unless it's enabled from the start by WithTaskTracking
+// or WithSlowTaskWatchdog. The tasks that were already running at that moment are not reported.
+func (p *poolCommon) InFlight() []TaskInfo {
+	p.tracked.Store(true)
+	p.trackersLock.Lock()
+	defer p.trackersLock.Unlock()
+	tasks := make([]TaskInfo, 0, len(p.trackers))
+	for t := range p.trackers {
+		if _, info, ok := t.load(); ok {
+			tasks = append(tasks, info)
+		}
+	}
+	return tasks
+}
+
+// IdleWorkers returns a snapshot of the idle workers waiting for tasks in this pool.
+// The GoroutineID of a worker is 0 if it has not run a task since the pool is tracked, see Pool.InFlight().
+func (p *poolCommon) IdleWorkers() []WorkerInfo {
+	p.tracked.Store(true)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	workers := make([]WorkerInfo, 0, p.workers.len())
+	p.workers.forEach(func(w worker) {
+		workers = append(workers, WorkerInfo{
+			GoroutineID: w.tracked().gid.Load(),
+			LastUsed:    w.lastUsedTime(),
+		})
+	})
+	return workers
+}
+
+// oldestRunning returns the age of the oldest running task.
+func (p *poolCommon) oldestRunning(now time.Time) (age time.Duration) {
+	p.trackersLock.Lock()
+	defer p.trackersLock.Unlock()
+	for t := range p.trackers {
+		if _, info, ok := t.load(); ok {
+			if d := now.Sub(info.StartTime); d > age {
+				age = d
+			}
+		}
+	}
+	return
+}
+
+type slowTask struct {
+	info    TaskInfo
+	elapsed time.Duration
+}
+
+// checkSlowTasks is called by the ticktock goroutine to report the tasks that have been running
+// longer than the threshold, each task is reported at most once.
+func (p *poolCommon) checkSlowTasks(now time.Time) {
+	handler, threshold := p.options.SlowTaskHandler, p.options.SlowTaskThreshold
+	if handler == nil || threshold <= 0 {
+		return
+	}
+
+	var slow []slowTask
+	p.trackersLock.Lock()
+	for t := range p.trackers {
+		seq, info, ok := t.load()
+		if !ok || t.reported.Load() == seq {
+			continue
+		}
+		if elapsed := now.Sub(info.StartTime); elapsed >= threshold {
+			t.reported.Store(seq)
+			slow = append(slow, slowTask{info, elapsed})
+		}
+	}
+	p.trackersLock.Unlock()
+	if len(slow) == 0 {
+		return
+	}
+
+	stacks := goroutineStacks()
+	for _, st := range slow {
+		handler(st.info, st.elapsed, findGoroutineStack(stacks, st.info.GoroutineID))
+	}
+}
@@ -0,0 +1,279 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+// SubmitWeighted submits a task that consumes weight units of the pool capacity while it's running.
+//
+// Every task submitted by the other methods weighs 1, a weighted task is only admitted when
+// the total weight of the running tasks plus its weight stays within the capacity of the pool.
+// The callers blocked on the capacity are admitted in the order they arrived, so a heavy task
+// is not starved by a stream of light ones. ErrInvalidTaskWeight is returned if the weight is
+// less than 1 or greater than the capacity.
+func (p *Pool) SubmitWeighted(weight int, task func()) error {
+	if p.IsClosed() {
+		return ErrPoolClosed
+	}
+
+	id, submitted := p.identify()
+	w, err := p.retrieveWeightedWorker("", weight, nil)
+	if w != nil {
+		w.tracked().submit(id, submitted)
+		w.inputFunc(task)
+	}
+	return err
+}
+
+// weightWaiter is a caller blocked until the capacity units it needs are available.
+type weightWaiter struct {
+	tenant *tenantState
+	weight int
+	start  float64 // the virtual start time
+	finish float64 // the virtual finish time
+}
+
+// reserveWeight reserves the capacity units for a task of the tenant, it must be called with p.lock held,
+// and it blocks until the units are available if block is true.
+func (p *poolCommon) reserveWeight(ts *tenantState, weight int, block bool) (err error) {
+	var (
+		waiter *weightWaiter
+		waited bool
+	)
+	for {
+		if capacity := p.Cap(); weight < 1 || (capacity != -1 && weight > capacity) {
+			err = ErrInvalidTaskWeight
+			break
+		}
+
+		if waiter == nil {
+			if len(p.weightWaiters) == 0 && p.acquireLocked(ts, weight) {
+				return nil
+			}
+			// A plain task keeps out of the queue while no one is queued, and it's woken up alone
+			// by signalLocked, as every plain task can take the capacity unit given back.
+			if len(p.weightWaiters) > 0 || ts != p.defaultTenant || weight > 1 {
+				// Take a place in the queue, the waiters ahead might be blocked by their own tenants.
+				waiter = p.enqueueWeightWaiter(ts, weight)
+			}
+		}
+
+		if waiter != nil && p.isNextLocked(waiter) && p.acquireLocked(ts, weight) {
+			p.vtime = max(p.vtime, waiter.start)
+			p.removeWeightWaiter(waiter)
+			return nil
+		}
+
+		// Bail out early if it's in nonblocking mode or the number of pending callers reaches the maximum limit value.
+		if !waited && (!block || p.options.Nonblocking ||
+			(p.options.MaxBlockingTasks != 0 && p.Waiting() >= p.options.MaxBlockingTasks)) {
+			if block {
+				p.overloaded.Add(1)
+			}
+			err = ErrPoolOverload
+			break
+		}
+
+		waited = true
+		p.addWaiting(1)
+		if waiter == nil {
+			// The capacity units are given back without the lock in the lock-free mode, which doesn't
+			// signal until it sees this caller waiting, so check again in case it has just missed it.
+			if p.lockFree && len(p.weightWaiters) == 0 && p.acquireLocked(ts, weight) {
+				p.addWaiting(-1)
+				return nil
+			}
+			ts.waiting++
+		}
+		p.cond.Wait()
+		if waiter == nil {
+			ts.waiting--
+		}
+		p.addWaiting(-1)
+
+		if p.IsClosed() {
+			err = ErrPoolClosed
+			break
+		}
+	}
+
+	ts.rejected++
+	if waiter != nil {
+		if ts.finish == waiter.finish {
+			// Give the virtual time back as the task never runs.
+			ts.finish = waiter.start
+		}
+		p.removeWeightWaiter(waiter)
+	}
+	p.evictLocked(ts)
+	return err
+}
+
+// enqueueWeightWaiter puts a waiter for the task of the tenant into the queue, ordered by the virtual
+// finish times of the waiters: every tenant is served in proportion to its weight when the pool is
+// saturated, and the tasks of the same tenant are served in the order they arrived.
+func (p *poolCommon) enqueueWeightWaiter(ts *tenantState, weight int) *weightWaiter {
+	waiter := &weightWaiter{
+		tenant: ts,
+		weight: weight,
+		start:  max(p.vtime, ts.finish),
+	}
+	waiter.finish = waiter.start + float64(weight)/float64(ts.weight)
+	ts.finish = waiter.finish
+	ts.waiting++
+	p.queued.Add(1)
+
+	i := len(p.weightWaiters)
+	for i > 0 && p.weightWaiters[i-1].finish > waiter.finish {
+		i--
+	}
+	p.weightWaiters = append(p.weightWaiters, nil)
+	copy(p.weightWaiters[i+1:], p.weightWaiters[i:])
+	p.weightWaiters[i] = waiter
+	return waiter
+}
+
+// isNextLocked reports whether the waiter can take its capacity units now, it must be called with p.lock held.
+//
+// The waiters are served in the order of the queue, except that a waiter blocked by the quota
+// of its own tenant doesn't hold up the others, neither does anyone hold up the waiters taking
+// the capacity units reserved for their tenants.
+func (p *poolCommon) isNextLocked(waiter *weightWaiter) bool {
+	blocked := false
+	for _, ww := range p.weightWaiters {
+		ts := ww.tenant
+		if ts.max > 0 && ts.running+ww.weight > ts.max {
+			continue
+		}
+		reserved := ts.running+ww.weight <= ts.reserved
+		if blocked && !reserved {
+			continue
+		}
+		if ww == waiter {
+			return p.fitsLocked(ts, int(p.inUse.Load()), ww.weight)
+		}
+		if !reserved {
+			blocked = true
+		}
+	}
+	return false
+}
+
+// fitsLocked reports whether the task of the tenant that weighs the given capacity units
+// fits into the pool with inUse units in use, it must be called with p.lock held.
+func (p *poolCommon) fitsLocked(ts *tenantState, inUse, weight int) bool {
+	if ts.max > 0 && ts.running+weight > ts.max {
+		return false
+	}
+	capacity := p.Cap()
+	if capacity == -1 {
+		return true
+	}
+	// The capacity units reserved for the other tenants are off-limits.
+	owed := p.owed - ts.owed() + max(0, ts.reserved-ts.running-weight)
+	return inUse+weight+owed <= capacity
+}
+
+// removeWeightWaiter removes the waiter from the queue and wakes up the others to
+// check whether it's their turn, it must be called with p.lock held.
+func (p *poolCommon) removeWeightWaiter(waiter *weightWaiter) {
+	for i, ww := range p.weightWaiters {
+		if ww == waiter {
+			p.weightWaiters = append(p.weightWaiters[:i], p.weightWaiters[i+1:]...)
+			break
+		}
+	}
+	waiter.tenant.waiting--
+	p.evictLocked(waiter.tenant)
+	p.queued.Add(-1)
+	if len(p.weightWaiters) > 0 {
+		p.cond.Broadcast()
+	}
+}
+
+// acquireLocked takes the capacity units for the task of the tenant if it fits into the pool,
+// it must be called with p.lock held. The units are taken by compare-and-swap as the lock-free
+// fast path takes them without the lock, see acquireFast.
+func (p *poolCommon) acquireLocked(ts *tenantState, weight int) bool {
+	for {
+		inUse := p.inUse.Load()
+		if !p.fitsLocked(ts, int(inUse), weight) {
+			return false
+		}
+		if p.inUse.CompareAndSwap(inUse, inUse+int32(weight)) {
+			break
+		}
+	}
+	p.owed -= ts.owed()
+	ts.running += weight
+	ts.admitted++
+	p.owed += ts.owed()
+	return true
+}
+
+// releaseLocked gives back the capacity units taken by acquireLocked, it must be called with p.lock held.
+func (p *poolCommon) releaseLocked(ts *tenantState, weight int) {
+	p.inUse.Add(-int32(weight))
+	p.owed -= ts.owed()
+	ts.running -= weight
+	p.owed += ts.owed()
+	p.evictLocked(ts)
+}
+
+// hold records the capacity units the current task takes on behalf of the tenant.
+func (t *taskTracker) hold(ts *tenantState, weight int) {
+	t.tenant = ts
+	t.weight = weight
+}
+
+// releaseWeightLocked gives the capacity units of the finished task back, it must be called with p.lock held.
+func (p *poolCommon) releaseWeightLocked(t *taskTracker) {
+	switch {
+	case t.weight == 0:
+	case t.tenant == nil:
+		// It's taken by the lock-free fast path.
+		p.inUse.Add(-int32(t.weight))
+		t.weight = 0
+	default:
+		p.unreserveLocked(t.tenant, t.weight)
+		t.tenant, t.weight = nil, 0
+	}
+}
+
+// signalLocked notifies the callers blocked on the pool of an available worker or capacity units,
+// it must be called with p.lock held.
+func (p *poolCommon) signalLocked() {
+	// All callers are woken up if any of them is queued or blocked on a worker, as the one woken up
+	// by Signal might not be the one that can proceed. Otherwise, they're all plain tasks waiting for
+	// a capacity unit, any of which can take it.
+	if len(p.weightWaiters) > 0 || p.workerWaiters > 0 {
+		p.cond.Broadcast()
+	} else {
+		p.cond.Signal()
+	}
+}
+
+// signal is like signalLocked but acquires p.lock itself.
+func (p *poolCommon) signal() {
+	p.lock.Lock()
+	p.signalLocked()
+	p.lock.Unlock()
+}
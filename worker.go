@@ -23,7 +23,6 @@
 package ants
 
 import (
-	"runtime/debug"
 	"time"
 )
 
@@ -41,6 +40,9 @@ type goWorker struct {
 
 	// lastUsed will be updated when putting a worker back into queue.
 	lastUsed time.Time
+
+	// tracker records the task the worker is running.
+	tracker taskTracker
 }
 
 // run starts a goroutine to repeat the process
@@ -48,29 +50,35 @@ type goWorker struct {
 func (w *goWorker) run() {
 	w.pool.addRunning(1)
 	go func() {
+		w.pool.track(&w.tracker)
 		defer func() {
+			r := recover()
+			var info TaskInfo
+			if r != nil {
+				info = w.tracker.current()
+			}
+			w.pool.untrack(&w.tracker)
 			if w.pool.addRunning(-1) == 0 && w.pool.IsClosed() {
 				w.pool.once.Do(func() {
 					close(w.pool.allDone)
 				})
 			}
 			w.pool.workerCache.Put(w)
-			if p := recover(); p != nil {
-				if ph := w.pool.options.PanicHandler; ph != nil {
-					ph(p)
-				} else {
-					w.pool.options.Logger.Printf("worker exits from panic: %v\n%s\n", p, debug.Stack())
-				}
+			if r != nil {
+				w.pool.handlePanic(r, info)
 			}
-			// Call Signal() here in case there are goroutines waiting for available workers.
-			w.pool.cond.Signal()
+			// Call signal() here in case there are goroutines waiting for available workers.
+			w.pool.signal()
+			w.pool.notifyIdle()
 		}()
 
 		for fn := range w.task {
 			if fn == nil {
 				return
 			}
+			w.pool.beginTask(&w.tracker)
 			fn()
+			w.pool.endTask(&w.tracker)
 			if ok := w.pool.revertWorker(w); !ok {
 				return
 			}
@@ -93,3 +101,7 @@ func (w *goWorker) setLastUsedTime(t time.Time) {
 func (w *goWorker) inputFunc(fn func()) {
 	w.task <- fn
 }
+
+func (w *goWorker) tracked() *taskTracker {
+	return &w.tracker
+}
@@ -23,7 +23,6 @@
 package ants
 
 import (
-	"runtime/debug"
 	"time"
 )
 
@@ -41,6 +40,9 @@ type goWorkerWithFunc struct {
 
 	// lastUsed will be updated when putting a worker back into queue.
 	lastUsed time.Time
+
+	// tracker records the task the worker is running.
+	tracker taskTracker
 }
 
 // run starts a goroutine to repeat the process
@@ -48,29 +50,37 @@ type goWorkerWithFunc struct {
 func (w *goWorkerWithFunc) run() {
 	w.pool.addRunning(1)
 	go func() {
+		w.pool.track(&w.tracker)
+		var arg any
 		defer func() {
+			r := recover()
+			var info TaskInfo
+			if r != nil {
+				info = w.tracker.current()
+				info.Arg = arg
+			}
+			w.pool.untrack(&w.tracker)
 			if w.pool.addRunning(-1) == 0 && w.pool.IsClosed() {
 				w.pool.once.Do(func() {
 					close(w.pool.allDone)
 				})
 			}
 			w.pool.workerCache.Put(w)
-			if p := recover(); p != nil {
-				if ph := w.pool.options.PanicHandler; ph != nil {
-					ph(p)
-				} else {
-					w.pool.options.Logger.Printf("worker exits from panic: %v\n%s\n", p, debug.Stack())
-				}
+			if r != nil {
+				w.pool.handlePanic(r, info)
 			}
-			// Call Signal() here in case there are goroutines waiting for available workers.
-			w.pool.cond.Signal()
+			// Call signal() here in case there are goroutines waiting for available workers.
+			w.pool.signal()
+			w.pool.notifyIdle()
 		}()
 
-		for arg := range w.arg {
+		for arg = range w.arg {
 			if arg == nil {
 				return
 			}
+			w.pool.beginTask(&w.tracker)
 			w.pool.fn(arg)
+			w.pool.endTask(&w.tracker)
 			if ok := w.pool.revertWorker(w); !ok {
 				return
 			}
@@ -93,3 +103,7 @@ func (w *goWorkerWithFunc) setLastUsedTime(t time.Time) {
 func (w *goWorkerWithFunc) inputArg(arg any) {
 	w.arg <- arg
 }
+
+func (w *goWorkerWithFunc) tracked() *taskTracker {
+	return &w.tracker
+}
@@ -23,7 +23,6 @@
 package ants
 
 import (
-	"runtime/debug"
 	"time"
 )
 
@@ -44,6 +43,9 @@ type goWorkerWithFuncGeneric[T any] struct {
 
 	// lastUsed will be updated when putting a worker back into queue.
 	lastUsed time.Time
+
+	// tracker records the task the worker is running.
+	tracker taskTracker
 }
 
 // run starts a goroutine to repeat the process
@@ -51,30 +53,38 @@ type goWorkerWithFuncGeneric[T any] struct {
 func (w *goWorkerWithFuncGeneric[T]) run() {
 	w.pool.addRunning(1)
 	go func() {
+		w.pool.track(&w.tracker)
+		var arg T
 		defer func() {
+			r := recover()
+			var info TaskInfo
+			if r != nil {
+				info = w.tracker.current()
+				info.Arg = arg
+			}
+			w.pool.untrack(&w.tracker)
 			if w.pool.addRunning(-1) == 0 && w.pool.IsClosed() {
 				w.pool.once.Do(func() {
 					close(w.pool.allDone)
 				})
 			}
 			w.pool.workerCache.Put(w)
-			if p := recover(); p != nil {
-				if ph := w.pool.options.PanicHandler; ph != nil {
-					ph(p)
-				} else {
-					w.pool.options.Logger.Printf("worker exits from panic: %v\n%s\n", p, debug.Stack())
-				}
+			if r != nil {
+				w.pool.handlePanic(r, info)
 			}
-			// Call Signal() here in case there are goroutines waiting for available workers.
-			w.pool.cond.Signal()
+			// Call signal() here in case there are goroutines waiting for available workers.
+			w.pool.signal()
+			w.pool.notifyIdle()
 		}()
 
 		for {
 			select {
 			case <-w.exit:
 				return
-			case arg := <-w.arg:
-				w.pool.fn(arg)
+			case arg = <-w.arg:
+				w.pool.beginTask(&w.tracker)
+				w.pool.runTask(&w.tracker, arg)
+				w.pool.endTask(&w.tracker)
 				if ok := w.pool.revertWorker(w); !ok {
 					return
 				}
@@ -94,3 +104,7 @@ func (w *goWorkerWithFuncGeneric[T]) lastUsedTime() time.Time {
 func (w *goWorkerWithFuncGeneric[T]) setLastUsedTime(t time.Time) {
 	w.lastUsed = t
 }
+
+func (w *goWorkerWithFuncGeneric[T]) tracked() *taskTracker {
+	return &w.tracker
+}
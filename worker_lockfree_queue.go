@@ -0,0 +1,198 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// cacheLinePad keeps the hot fields of lockFreeQueue off each other's cache lines.
+type cacheLinePad [64]byte
+
+// lockFreeCell is a slot of lockFreeQueue, its sequence tells the producers and the consumers
+// whether the slot is ready for them at a given position of the ring.
+//
+// The sequence of a slot holding a worker is marked by cellLocked while the worker is being read
+// in place by peek or taken out by take, so that neither of them races with the other.
+type lockFreeCell struct {
+	seq atomic.Uint64
+	w   worker
+}
+
+// lockFreeQueue is a bounded multi-producer multi-consumer ring of the idle workers, all its methods
+// are safe for concurrent use without any lock. The workers are detached in the order they were
+// inserted, like loopQueue.
+//
+// The head and the tail are positions that keep increasing rather than wrapping around the ring,
+// a slot is only taken at a position its sequence matches, hence the ABA problem is ruled out.
+type lockFreeQueue struct {
+	_    cacheLinePad
+	head atomic.Uint64 // the position to detach the next worker from
+	_    cacheLinePad
+	tail atomic.Uint64 // the position to insert the next worker at
+	_    cacheLinePad
+
+	size  uint64
+	cells []lockFreeCell
+
+	// expiry is only touched by refresh, which is called with the pool lock held.
+	expiry []worker
+}
+
+// cellLocked marks the sequence of a locked slot, see lockFreeCell.
+const cellLocked = 1 << 63
+
+func newLockFreeQueue(size int) *lockFreeQueue {
+	if size <= 0 {
+		return nil
+	}
+	q := &lockFreeQueue{
+		size:  uint64(size),
+		cells: make([]lockFreeCell, size),
+	}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+func (q *lockFreeQueue) len() int {
+	head, tail := q.head.Load(), q.tail.Load()
+	if tail <= head {
+		return 0
+	}
+	return int(min(tail-head, q.size))
+}
+
+func (q *lockFreeQueue) isEmpty() bool {
+	return q.len() == 0
+}
+
+func (q *lockFreeQueue) insert(w worker) error {
+	pos := q.tail.Load()
+	for {
+		c := &q.cells[pos%q.size]
+		switch seq := c.seq.Load(); {
+		case seq&cellLocked != 0:
+			runtime.Gosched()
+			pos = q.tail.Load()
+		case seq == pos:
+			if q.tail.CompareAndSwap(pos, pos+1) {
+				c.w = w
+				c.seq.Store(pos + 1)
+				return nil
+			}
+			pos = q.tail.Load()
+		case seq < pos:
+			if head := q.head.Load(); pos >= head && pos-head >= q.size {
+				return errQueueIsFull
+			}
+			// The worker inserted a round ago at this slot is being detached, wait for it to be done.
+			runtime.Gosched()
+			pos = q.tail.Load()
+		default:
+			pos = q.tail.Load()
+		}
+	}
+}
+
+func (q *lockFreeQueue) detach() worker {
+	pos := q.head.Load()
+	for {
+		c := &q.cells[pos%q.size]
+		switch seq := c.seq.Load(); {
+		case seq&cellLocked != 0:
+			runtime.Gosched()
+			pos = q.head.Load()
+		case seq == pos+1:
+			if q.head.CompareAndSwap(pos, pos+1) {
+				return q.take(pos)
+			}
+			pos = q.head.Load()
+		case seq < pos+1:
+			// Nothing has been inserted at this position yet, or the worker is being inserted,
+			// which is no different from an empty queue to the callers.
+			return nil
+		default:
+			pos = q.head.Load()
+		}
+	}
+}
+
+// take takes the worker out of the slot at the position, which has just been detached from the head.
+func (q *lockFreeQueue) take(pos uint64) worker {
+	c := &q.cells[pos%q.size]
+	// Wait for peek to be done with the worker.
+	for !c.seq.CompareAndSwap(pos+1, (pos+1)|cellLocked) {
+		runtime.Gosched()
+	}
+	w := c.w
+	c.w = nil // avoid memory leaks
+	c.seq.Store(pos + q.size)
+	return w
+}
+
+// peek calls fn with the worker at the position without detaching it, the worker is neither detached
+// nor put back until fn returns. It returns false if there is no worker at the position.
+func (q *lockFreeQueue) peek(pos uint64, fn func(worker)) bool {
+	c := &q.cells[pos%q.size]
+	if !c.seq.CompareAndSwap(pos+1, (pos+1)|cellLocked) {
+		return false
+	}
+	fn(c.w)
+	c.seq.Store(pos + 1)
+	return true
+}
+
+// refresh detaches the workers from the head of the queue while they're stale,
+// the workers after the first one that is not stale are not stale either.
+func (q *lockFreeQueue) refresh(now time.Time, duration time.Duration) []worker {
+	expiryTime := now.Add(-duration)
+	q.expiry = q.expiry[:0]
+	for {
+		pos, stale := q.head.Load(), false
+		if !q.peek(pos, func(w worker) { stale = !expiryTime.Before(w.lastUsedTime()) }) || !stale {
+			break
+		}
+		// The worker might have been taken by a caller since it was peeked.
+		if q.head.CompareAndSwap(pos, pos+1) {
+			q.expiry = append(q.expiry, q.take(pos))
+		}
+	}
+	return q.expiry
+}
+
+// forEach reads the workers in place, the workers detached or inserted meanwhile might be missed.
+func (q *lockFreeQueue) forEach(fn func(worker)) {
+	for pos, tail := q.head.Load(), q.tail.Load(); pos < tail; pos++ {
+		q.peek(pos, fn)
+	}
+}
+
+func (q *lockFreeQueue) reset() {
+	for w := q.detach(); w != nil; w = q.detach() {
+		w.finish()
+	}
+}
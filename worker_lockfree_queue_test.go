@@ -0,0 +1,130 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLockFreeQueue(t *testing.T) {
+	size := 100
+	q := newLockFreeQueue(size)
+	require.EqualValues(t, 0, q.len(), "Len error")
+	require.Equal(t, true, q.isEmpty(), "IsEmpty error")
+	require.Nil(t, q.detach(), "Dequeue error")
+
+	require.Nil(t, newLockFreeQueue(0))
+}
+
+func TestLockFreeQueue(t *testing.T) {
+	size := 10
+	q := newLockFreeQueue(size)
+
+	for i := 0; i < 5; i++ {
+		err := q.insert(&goWorker{lastUsed: time.Now()})
+		if err != nil {
+			break
+		}
+	}
+	require.EqualValues(t, 5, q.len(), "Len error")
+	_ = q.detach()
+	require.EqualValues(t, 4, q.len(), "Len error")
+
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		err := q.insert(&goWorker{lastUsed: now.Add(time.Second)})
+		if err != nil {
+			break
+		}
+	}
+	require.EqualValues(t, 10, q.len(), "Len error")
+
+	err := q.insert(&goWorker{lastUsed: time.Now()})
+	require.ErrorIs(t, err, errQueueIsFull, "Enqueue, error")
+
+	// The workers are read in place, they're neither detached nor reordered.
+	n := 0
+	q.forEach(func(worker) {
+		require.EqualValues(t, 10, q.len(), "Len error")
+		n++
+	})
+	require.EqualValues(t, 10, n)
+
+	// The first worker that is not stale stays at the head.
+	first := q.cells[(q.head.Load()+4)%q.size].w
+	workers := q.refresh(now.Add(time.Second), time.Second)
+	require.Len(t, workers, 4)
+	require.EqualValuesf(t, 6, q.len(), "Len error: %d", q.len())
+	require.Same(t, first, q.detach())
+}
+
+func TestLockFreeQueueConcurrency(t *testing.T) {
+	size := 64
+	q := newLockFreeQueue(size)
+	for i := 0; i < size; i++ {
+		require.NoError(t, q.insert(&goWorker{lastUsed: time.Now()}))
+	}
+
+	// Every worker is held by one goroutine at most, and none of them is lost.
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		held = make(map[worker]bool)
+	)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for j := 0; j < 1000; j++ {
+			q.forEach(func(w worker) { _ = w.lastUsedTime() })
+			_ = q.refresh(time.Now(), time.Hour)
+		}
+	}()
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				w := q.detach()
+				if w == nil {
+					continue
+				}
+				mu.Lock()
+				require.False(t, held[w])
+				held[w] = true
+				mu.Unlock()
+
+				mu.Lock()
+				delete(held, w)
+				mu.Unlock()
+				require.NoError(t, q.insert(w))
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+	require.EqualValues(t, size, q.len())
+}
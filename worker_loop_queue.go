@@ -91,8 +91,8 @@ func (wq *loopQueue) detach() worker {
 	return w
 }
 
-func (wq *loopQueue) refresh(duration time.Duration) []worker {
-	expiryTime := time.Now().Add(-duration)
+func (wq *loopQueue) refresh(now time.Time, duration time.Duration) []worker {
+	expiryTime := now.Add(-duration)
 	index := wq.binarySearch(expiryTime)
 	if index == -1 {
 		return nil
@@ -160,6 +160,12 @@ func (wq *loopQueue) binarySearch(expiryTime time.Time) int {
 	return (r + basel + nlen) % nlen
 }
 
+func (wq *loopQueue) forEach(fn func(worker)) {
+	for i, n := 0, wq.len(); i < n; i++ {
+		fn(wq.items[(wq.head+i)%wq.size])
+	}
+}
+
 func (wq *loopQueue) reset() {
 	if wq.isEmpty() {
 		return
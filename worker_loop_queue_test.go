@@ -67,7 +67,7 @@ func TestLoopQueue(t *testing.T) {
 	err := q.insert(&goWorker{lastUsed: time.Now()})
 	require.Error(t, err, "Enqueue, error")
 
-	q.refresh(time.Second)
+	q.refresh(time.Now(), time.Second)
 	require.EqualValuesf(t, 6, q.len(), "Len error: %d", q.len())
 }
 
@@ -164,7 +164,7 @@ func TestRetrieveExpiry(t *testing.T) {
 	for i := 0; i < size/2; i++ {
 		_ = q.insert(&goWorker{lastUsed: time.Now()})
 	}
-	workers := q.refresh(u)
+	workers := q.refresh(time.Now(), u)
 
 	require.EqualValues(t, expirew, workers, "expired workers aren't right")
 
@@ -177,7 +177,7 @@ func TestRetrieveExpiry(t *testing.T) {
 	expirew = expirew[:0]
 	expirew = append(expirew, q.items[size/2:]...)
 
-	workers2 := q.refresh(u)
+	workers2 := q.refresh(time.Now(), u)
 
 	require.EqualValues(t, expirew, workers2, "expired workers aren't right")
 
@@ -197,7 +197,7 @@ func TestRetrieveExpiry(t *testing.T) {
 	expirew = append(expirew, q.items[0:3]...)
 	expirew = append(expirew, q.items[size/2:]...)
 
-	workers3 := q.refresh(u)
+	workers3 := q.refresh(time.Now(), u)
 
 	require.EqualValues(t, expirew, workers3, "expired workers aren't right")
 }
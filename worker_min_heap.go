@@ -0,0 +1,105 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"container/heap"
+	"time"
+)
+
+// workerHeap is a min-heap of the idle workers ordered by the time they were last used.
+type workerHeap []worker
+
+func (h workerHeap) Len() int           { return len(h) }
+func (h workerHeap) Less(i, j int) bool { return h[i].lastUsedTime().Before(h[j].lastUsedTime()) }
+func (h workerHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *workerHeap) Push(x any) {
+	*h = append(*h, x.(worker))
+}
+
+func (h *workerHeap) Pop() any {
+	old := *h
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil // avoid memory leaks
+	*h = old[:n-1]
+	return w
+}
+
+// workerMinHeap keeps the idle workers in the order they're due to expire, the worker that has been
+// idle for the longest time is detached first, and the stale workers are purged from the top without
+// searching, whichever order the workers were put back in.
+type workerMinHeap struct {
+	items  workerHeap
+	expiry []worker
+}
+
+func newWorkerMinHeap(size int) *workerMinHeap {
+	return &workerMinHeap{
+		items: make(workerHeap, 0, size),
+	}
+}
+
+func (wh *workerMinHeap) len() int {
+	return len(wh.items)
+}
+
+func (wh *workerMinHeap) isEmpty() bool {
+	return len(wh.items) == 0
+}
+
+func (wh *workerMinHeap) insert(w worker) error {
+	heap.Push(&wh.items, w)
+	return nil
+}
+
+func (wh *workerMinHeap) detach() worker {
+	if wh.isEmpty() {
+		return nil
+	}
+	return heap.Pop(&wh.items).(worker)
+}
+
+func (wh *workerMinHeap) refresh(now time.Time, duration time.Duration) []worker {
+	expiryTime := now.Add(-duration)
+	wh.expiry = wh.expiry[:0]
+	for !wh.isEmpty() && !expiryTime.Before(wh.items[0].lastUsedTime()) {
+		wh.expiry = append(wh.expiry, heap.Pop(&wh.items).(worker))
+	}
+	return wh.expiry
+}
+
+func (wh *workerMinHeap) forEach(fn func(worker)) {
+	for _, w := range wh.items {
+		fn(w)
+	}
+}
+
+func (wh *workerMinHeap) reset() {
+	for i := 0; i < wh.len(); i++ {
+		wh.items[i].finish()
+		wh.items[i] = nil
+	}
+	wh.items = wh.items[:0]
+}
@@ -0,0 +1,121 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkerMinHeap(t *testing.T) {
+	size := 100
+	q := newWorkerMinHeap(size)
+	require.EqualValues(t, 0, q.len(), "Len error")
+	require.Equal(t, true, q.isEmpty(), "IsEmpty error")
+	require.Nil(t, q.detach(), "Dequeue error")
+}
+
+func TestWorkerMinHeap(t *testing.T) {
+	q := newWorkerQueue(queueTypeMinHeap, 0)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		err := q.insert(&goWorker{lastUsed: now.Add(time.Duration(i) * time.Second)})
+		if err != nil {
+			t.Fatal("Enqueue error")
+		}
+	}
+	require.EqualValues(t, 5, q.len(), "Len error")
+
+	// The worker idle for the longest time is detached first, even if it's put back last.
+	oldest := &goWorker{lastUsed: now.Add(-time.Second)}
+	err := q.insert(oldest)
+	if err != nil {
+		t.Fatal("Enqueue error")
+	}
+	require.Same(t, oldest, q.detach(), "Dequeue error")
+	require.EqualValues(t, 5, q.len(), "Len error")
+
+	for i := 0; i < 6; i++ {
+		err := q.insert(&goWorker{lastUsed: now.Add(10 * time.Second)})
+		if err != nil {
+			t.Fatal("Enqueue error")
+		}
+	}
+	require.EqualValues(t, 11, q.len(), "Len error")
+
+	n := 0
+	q.forEach(func(worker) { n++ })
+	require.EqualValues(t, 11, n)
+
+	workers := q.refresh(now.Add(10*time.Second), 6*time.Second)
+	require.Len(t, workers, 5)
+	for i, w := range workers {
+		require.Equal(t, now.Add(time.Duration(i)*time.Second), w.lastUsedTime(), "expired workers aren't in order")
+	}
+	require.EqualValues(t, 6, q.len(), "Len error")
+}
+
+// The workers put back out of order are still purged exactly.
+func TestWorkerMinHeapRefresh(t *testing.T) {
+	q := newWorkerMinHeap(0)
+
+	now := time.Now()
+	for _, d := range []int{3, 9, 1, 7, 5, 8, 2, 6, 4} {
+		_ = q.insert(&goWorker{lastUsed: now.Add(time.Duration(d) * time.Second)})
+	}
+	require.Len(t, q.refresh(now.Add(10*time.Second), 5*time.Second), 5)
+	require.EqualValues(t, 4, q.len(), "Len error")
+	require.Equal(t, now.Add(6*time.Second), q.detach().lastUsedTime())
+
+	require.Empty(t, q.refresh(now.Add(10*time.Second), 5*time.Second))
+	require.EqualValues(t, 3, q.len(), "Len error")
+}
+
+func TestNewKindOfWorkerQueue(t *testing.T) {
+	kinds := []struct {
+		kind     WorkerQueueKind
+		preAlloc bool
+		expected workerQueue
+	}{
+		{DefaultWorkerQueue, false, &workerStack{}},
+		{DefaultWorkerQueue, true, &loopQueue{}},
+		{WorkerQueueLIFO, true, &workerStack{}},
+		{WorkerQueueFIFO, false, &loopQueue{}},
+		{WorkerQueueMinHeap, false, &workerMinHeap{}},
+	}
+	for _, k := range kinds {
+		q, err := newKindOfWorkerQueue(k.kind, 10, k.preAlloc)
+		require.NoError(t, err)
+		require.IsType(t, k.expected, q)
+	}
+
+	_, err := newKindOfWorkerQueue(WorkerQueueFIFO, -1, false)
+	require.ErrorIs(t, err, ErrInvalidWorkerQueue)
+	_, err = newKindOfWorkerQueue(WorkerQueueMinHeap, -1, true)
+	require.ErrorIs(t, err, ErrInvalidPreAllocSize)
+	_, err = newKindOfWorkerQueue(WorkerQueueKind(-1), 10, false)
+	require.ErrorIs(t, err, ErrInvalidWorkerQueue)
+}
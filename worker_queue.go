@@ -37,6 +37,7 @@ type worker interface {
 	setLastUsedTime(t time.Time)
 	inputFunc(func())
 	inputArg(any)
+	tracked() *taskTracker
 }
 
 type workerQueue interface {
@@ -44,15 +45,40 @@ type workerQueue interface {
 	isEmpty() bool
 	insert(worker) error
 	detach() worker
-	refresh(duration time.Duration) []worker // clean up the stale workers and return them
+	refresh(now time.Time, duration time.Duration) []worker // clean up the stale workers and return them
 	reset()
+	forEach(fn func(worker))
 }
 
+// WorkerQueueKind represents the type of queue keeping the idle workers of a pool,
+// which decides the order the idle workers are reused in, see WithWorkerQueue.
+type WorkerQueueKind int
+
+const (
+	// DefaultWorkerQueue is WorkerQueueLIFO, or WorkerQueueFIFO under PreAlloc mode.
+	DefaultWorkerQueue WorkerQueueKind = iota
+
+	// WorkerQueueLIFO reuses the worker put back most recently, which is likely still hot in cache,
+	// and leaves the others idle to expire, so the pool shrinks quickly once the load drops.
+	WorkerQueueLIFO
+
+	// WorkerQueueFIFO reuses the worker that has been idle for the longest time, which spreads the tasks
+	// evenly over the workers, it requires a limited capacity as the queue is a ring of the same size.
+	WorkerQueueFIFO
+
+	// WorkerQueueMinHeap reuses the worker that is due to expire first, like WorkerQueueFIFO, but it keeps
+	// the workers strictly in the order of their expiry times and doesn't require a limited capacity.
+	WorkerQueueMinHeap
+)
+
 type queueType int
 
 const (
 	queueTypeStack queueType = 1 << iota
 	queueTypeLoopQueue
+	queueTypeLockFree
+	queueTypeSharded
+	queueTypeMinHeap
 )
 
 func newWorkerQueue(qType queueType, size int) workerQueue {
@@ -61,7 +87,45 @@ func newWorkerQueue(qType queueType, size int) workerQueue {
 		return newWorkerStack(size)
 	case queueTypeLoopQueue:
 		return newWorkerLoopQueue(size)
+	case queueTypeLockFree:
+		return newLockFreeQueue(size)
+	case queueTypeSharded:
+		return newShardedQueue(size)
+	case queueTypeMinHeap:
+		return newWorkerMinHeap(size)
 	default:
 		return newWorkerStack(size)
 	}
 }
+
+// newKindOfWorkerQueue returns the worker queue of the kind for a pool of the capacity,
+// the memory of the queue is allocated for the capacity upfront if preAlloc is true.
+func newKindOfWorkerQueue(kind WorkerQueueKind, size int, preAlloc bool) (workerQueue, error) {
+	if preAlloc && size == -1 {
+		return nil, ErrInvalidPreAllocSize
+	}
+	if kind == DefaultWorkerQueue {
+		kind = WorkerQueueLIFO
+		if preAlloc {
+			kind = WorkerQueueFIFO
+		}
+	}
+
+	n := 0
+	if preAlloc {
+		n = size
+	}
+	switch kind {
+	case WorkerQueueLIFO:
+		return newWorkerQueue(queueTypeStack, n), nil
+	case WorkerQueueFIFO:
+		if size == -1 {
+			return nil, ErrInvalidWorkerQueue
+		}
+		return newWorkerQueue(queueTypeLoopQueue, size), nil
+	case WorkerQueueMinHeap:
+		return newWorkerQueue(queueTypeMinHeap, n), nil
+	default:
+		return nil, ErrInvalidWorkerQueue
+	}
+}
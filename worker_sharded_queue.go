@@ -0,0 +1,140 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	syncx "github.com/panjf2000/ants/v2/pkg/sync"
+)
+
+// workerShard is an idle worker list of shardedQueue guarded by its own lock.
+type workerShard struct {
+	lock  sync.Locker
+	stack *workerStack
+	n     atomic.Int32 // the length of stack, which is read without the lock
+	_     cacheLinePad
+}
+
+// shardedQueue spreads the idle workers over a number of shards, as many as GOMAXPROCS in a pool,
+// so that the callers rarely contend for the same lock, all its methods are safe for concurrent use.
+//
+// Go doesn't tell which P the caller is running on, so the shards are not per-P: every caller picks
+// a shard at random by fastrandn, which spreads the contention as well without taking any lock.
+// A worker is put back into the shard picked, and taken from the shard picked or stolen from
+// the others in turn if that one is empty.
+type shardedQueue struct {
+	shards []workerShard
+
+	seed uint32
+	_    cacheLinePad
+
+	// expiry is only touched by refresh, which is called with the pool lock held.
+	expiry []worker
+}
+
+func newShardedQueue(shards int) *shardedQueue {
+	if shards <= 0 {
+		return nil
+	}
+	q := &shardedQueue{shards: make([]workerShard, shards)}
+	for i := range q.shards {
+		q.shards[i].lock = syncx.NewSpinLock()
+		q.shards[i].stack = newWorkerStack(0)
+	}
+	return q
+}
+
+func (q *shardedQueue) len() (n int) {
+	for i := range q.shards {
+		n += int(q.shards[i].n.Load())
+	}
+	return
+}
+
+func (q *shardedQueue) isEmpty() bool {
+	return q.len() == 0
+}
+
+func (q *shardedQueue) pick() int {
+	return int(fastrandn(&q.seed, uint32(len(q.shards))))
+}
+
+func (q *shardedQueue) insert(w worker) error {
+	s := &q.shards[q.pick()]
+	s.lock.Lock()
+	_ = s.stack.insert(w)
+	s.n.Store(int32(s.stack.len()))
+	s.lock.Unlock()
+	return nil
+}
+
+func (q *shardedQueue) detach() worker {
+	for i, start := 0, q.pick(); i < len(q.shards); i++ {
+		s := &q.shards[(start+i)%len(q.shards)]
+		if s.n.Load() == 0 {
+			continue
+		}
+		s.lock.Lock()
+		w := s.stack.detach()
+		s.n.Store(int32(s.stack.len()))
+		s.lock.Unlock()
+		if w != nil {
+			return w
+		}
+	}
+	return nil
+}
+
+func (q *shardedQueue) refresh(now time.Time, duration time.Duration) []worker {
+	q.expiry = q.expiry[:0]
+	for i := range q.shards {
+		s := &q.shards[i]
+		s.lock.Lock()
+		q.expiry = append(q.expiry, s.stack.refresh(now, duration)...)
+		s.n.Store(int32(s.stack.len()))
+		s.lock.Unlock()
+	}
+	return q.expiry
+}
+
+func (q *shardedQueue) forEach(fn func(worker)) {
+	for i := range q.shards {
+		s := &q.shards[i]
+		s.lock.Lock()
+		s.stack.forEach(fn)
+		s.lock.Unlock()
+	}
+}
+
+func (q *shardedQueue) reset() {
+	for i := range q.shards {
+		s := &q.shards[i]
+		s.lock.Lock()
+		s.stack.reset()
+		s.n.Store(0)
+		s.lock.Unlock()
+	}
+}
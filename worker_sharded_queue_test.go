@@ -0,0 +1,92 @@
+// MIT License
+
+// Copyright (c) 2025 Andy Pan
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package ants
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardedQueue(t *testing.T) {
+	q := newShardedQueue(4)
+	require.EqualValues(t, 0, q.len(), "Len error")
+	require.Equal(t, true, q.isEmpty(), "IsEmpty error")
+	require.Nil(t, q.detach(), "Dequeue error")
+
+	require.Nil(t, newShardedQueue(0))
+}
+
+func TestShardedQueue(t *testing.T) {
+	q := newShardedQueue(4)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.insert(&goWorker{lastUsed: now.Add(-time.Second)}))
+	}
+	for i := 0; i < 6; i++ {
+		require.NoError(t, q.insert(&goWorker{lastUsed: now}))
+	}
+	require.EqualValues(t, 11, q.len(), "Len error")
+
+	n := 0
+	q.forEach(func(worker) { n++ })
+	require.EqualValues(t, 11, n)
+
+	// The stale workers are purged from all shards.
+	workers := q.refresh(now, time.Second/2)
+	require.Len(t, workers, 5)
+	require.EqualValuesf(t, 6, q.len(), "Len error: %d", q.len())
+
+	// The workers are stolen from the other shards until all of them are taken.
+	for i := 0; i < 6; i++ {
+		require.NotNil(t, q.detach())
+	}
+	require.True(t, q.isEmpty())
+	require.Nil(t, q.detach())
+}
+
+func TestShardedQueueConcurrency(t *testing.T) {
+	size := 64
+	q := newShardedQueue(8)
+	for i := 0; i < size; i++ {
+		require.NoError(t, q.insert(&goWorker{}))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10000; j++ {
+				if w := q.detach(); w != nil {
+					_ = q.insert(w)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, size, q.len())
+}
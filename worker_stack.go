@@ -61,13 +61,13 @@ func (ws *workerStack) detach() worker {
 	return w
 }
 
-func (ws *workerStack) refresh(duration time.Duration) []worker {
+func (ws *workerStack) refresh(now time.Time, duration time.Duration) []worker {
 	n := ws.len()
 	if n == 0 {
 		return nil
 	}
 
-	expiryTime := time.Now().Add(-duration)
+	expiryTime := now.Add(-duration)
 	index := ws.binarySearch(0, n-1, expiryTime)
 
 	ws.expiry = ws.expiry[:0]
@@ -82,6 +82,12 @@ func (ws *workerStack) refresh(duration time.Duration) []worker {
 	return ws.expiry
 }
 
+func (ws *workerStack) forEach(fn func(worker)) {
+	for _, w := range ws.items {
+		fn(w)
+	}
+}
+
 func (ws *workerStack) binarySearch(l, r int, expiryTime time.Time) int {
 	for l <= r {
 		mid := l + ((r - l) >> 1) // avoid overflow when computing mid
@@ -65,7 +65,7 @@ func TestWorkerStack(t *testing.T) {
 		}
 	}
 	require.EqualValues(t, 12, q.len(), "Len error")
-	q.refresh(time.Second)
+	q.refresh(time.Now(), time.Second)
 	require.EqualValues(t, 6, q.len(), "Len error")
 }
 